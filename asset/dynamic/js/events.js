@@ -0,0 +1,31 @@
+// Refresh the notepad list when a note is added, changed, or removed in
+// another window or by another device.
+$(function() {
+	var list = $('#note-list');
+	if (!list.length || !window.EventSource) {
+		return;
+	}
+
+	var refresh = _.debounce(function() {
+		$.get(window.location.href, function(html) {
+			var page = $('<div>').html(html);
+
+			// Keep the selected items selected
+			var selected = $('input[name="id"]:checked', list).map(function() {
+				return this.value;
+			}).get();
+
+			list.html(page.find('#note-list').html());
+			$('#note-pager').html(page.find('#note-pager').html());
+
+			_.each(selected, function(id) {
+				$('input[name="id"][value="' + id + '"]', list).prop('checked', true);
+			});
+		});
+	}, 500);
+
+	var source = new EventSource(list.data('events'));
+	_.each(['note.created', 'note.updated', 'note.deleted'], function(type) {
+		source.addEventListener(type, refresh);
+	});
+});
\ No newline at end of file
@@ -0,0 +1,36 @@
+// Toggle the pin and favorite flags on a note without reloading the page.
+$(function() {
+	$(document).on('submit', '.toggle-form', function(e) {
+		e.preventDefault();
+
+		var form = $(this);
+		var flag = form.data('flag');
+
+		$.ajax({
+			url: form.attr('action'),
+			method: 'POST',
+			data: form.serialize(),
+			dataType: 'json'
+		}).done(function(data) {
+			var on = data[flag];
+			form.find('input[name="value"]').val(on ? '0' : '1');
+			form.find('button').toggleClass('active', on);
+
+			// Keep the pinned notes at the top of the list
+			if (flag == 'pinned') {
+				var panel = form.closest('.note-panel');
+				panel.toggleClass('pinned', on);
+				if (on) {
+					$('#note-list').prepend(panel);
+				} else {
+					var last = $('#note-list .note-panel.pinned').last();
+					if (last.length) {
+						panel.insertAfter(last);
+					}
+				}
+			}
+		}).fail(function() {
+			flashError('The item could not be updated. Please try again later.');
+		});
+	});
+});
\ No newline at end of file
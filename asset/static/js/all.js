@@ -1,3 +1,293 @@
+// Edit a note together with other windows. Each change is sent to the server
+// as an operation: a list of retains (positive numbers), deletes (negative
+// numbers), and inserts (strings). Changes made at the same time are merged
+// with operational transform.
+$(function() {
+	var area = $('#collab-editor');
+	if (!area.length || !window.WebSocket) {
+		return;
+	}
+
+	function isRetain(c) { return typeof c === 'number' && c > 0; }
+	function isDelete(c) { return typeof c === 'number' && c < 0; }
+	function isInsert(c) { return typeof c === 'string'; }
+
+	function retain(op, n) {
+		if (n <= 0) return;
+		var l = op.length;
+		if (l && isRetain(op[l - 1])) op[l - 1] += n;
+		else op.push(n);
+	}
+
+	function remove(op, n) {
+		if (n <= 0) return;
+		var l = op.length;
+		if (l && isDelete(op[l - 1])) op[l - 1] -= n;
+		else op.push(-n);
+	}
+
+	// Inserts are always placed before deletes at the same position
+	function insert(op, s) {
+		if (!s) return;
+		var l = op.length;
+		if (l && isInsert(op[l - 1])) {
+			op[l - 1] += s;
+		} else if (l && isDelete(op[l - 1])) {
+			if (l > 1 && isInsert(op[l - 2])) {
+				op[l - 2] += s;
+			} else {
+				op.push(op[l - 1]);
+				op[l - 1] = s;
+			}
+		} else {
+			op.push(s);
+		}
+	}
+
+	function apply(op, text) {
+		var out = [], i = 0;
+		_.each(op, function(c) {
+			if (isRetain(c)) {
+				out.push(text.slice(i, i + c));
+				i += c;
+			} else if (isDelete(c)) {
+				i -= c;
+			} else {
+				out.push(c);
+			}
+		});
+		return out.join('');
+	}
+
+	// Returns a pair of operations so applying a then b1 is the same as
+	// applying b then a1.
+	function transform(a, b) {
+		var a1 = [], b1 = [], i = 0, j = 0, ca = a[i++], cb = b[j++], n;
+		while (ca !== undefined || cb !== undefined) {
+			if (isInsert(ca)) {
+				insert(a1, ca);
+				retain(b1, ca.length);
+				ca = a[i++];
+				continue;
+			}
+			if (isInsert(cb)) {
+				retain(a1, cb.length);
+				insert(b1, cb);
+				cb = b[j++];
+				continue;
+			}
+
+			n = Math.min(Math.abs(ca), Math.abs(cb));
+			if (isRetain(ca) && isRetain(cb)) {
+				retain(a1, n);
+				retain(b1, n);
+			} else if (isDelete(ca) && isRetain(cb)) {
+				remove(a1, n);
+			} else if (isRetain(ca) && isDelete(cb)) {
+				remove(b1, n);
+			}
+
+			ca = ca > 0 ? ca - n : ca + n;
+			if (ca === 0) ca = a[i++];
+			cb = cb > 0 ? cb - n : cb + n;
+			if (cb === 0) cb = b[j++];
+		}
+		return [a1, b1];
+	}
+
+	// Returns an operation that is the same as applying a then b.
+	function compose(a, b) {
+		var op = [], i = 0, j = 0, ca = a[i++], cb = b[j++], n;
+		while (ca !== undefined || cb !== undefined) {
+			if (isDelete(ca)) {
+				remove(op, -ca);
+				ca = a[i++];
+				continue;
+			}
+			if (isInsert(cb)) {
+				insert(op, cb);
+				cb = b[j++];
+				continue;
+			}
+
+			if (isInsert(ca)) {
+				n = isRetain(cb) ? cb : -cb;
+				if (isRetain(cb)) insert(op, ca.slice(0, n));
+				if (ca.length > n) {
+					ca = ca.slice(n);
+					cb = b[j++];
+					continue;
+				}
+				cb = isRetain(cb) ? cb - ca.length : cb + ca.length;
+				ca = a[i++];
+			} else {
+				n = Math.min(ca, Math.abs(cb));
+				if (isRetain(cb)) retain(op, n);
+				else remove(op, n);
+				ca -= n;
+				cb = cb > 0 ? cb - n : cb + n;
+			}
+
+			if (ca === 0) ca = a[i++];
+			if (cb === 0) cb = b[j++];
+		}
+		return op;
+	}
+
+	// Returns the operation that changes text a into text b
+	function diff(a, b) {
+		var start = 0, end = 0, op = [];
+		while (start < a.length && start < b.length && a[start] === b[start]) start++;
+		while (end < a.length - start && end < b.length - start &&
+			a[a.length - 1 - end] === b[b.length - 1 - end]) end++;
+		retain(op, start);
+		insert(op, b.slice(start, b.length - end));
+		remove(op, a.length - start - end);
+		retain(op, end);
+		return op;
+	}
+
+	// Returns the position of the cursor after the operation is applied
+	function cursor(op, pos) {
+		var i = 0, result = pos;
+		_.each(op, function(c) {
+			if (i >= pos) return;
+			if (isRetain(c)) {
+				i += c;
+			} else if (isDelete(c)) {
+				result -= Math.min(-c, pos - i);
+				i -= c;
+			} else {
+				result += c.length;
+			}
+		});
+		return result;
+	}
+
+	var el = area[0];
+	var text = area.val();
+	var revision = 0;
+	// The operation waiting for the server and the changes made since
+	var outstanding = null, buffer = null;
+
+	var url = (location.protocol === 'https:' ? 'wss:' : 'ws:') + '//' + location.host + area.data('url');
+	var socket = new WebSocket(url);
+
+	function send(op) {
+		socket.send(JSON.stringify({type: 'op', revision: revision, op: op}));
+	}
+
+	function local() {
+		var value = area.val();
+		if (value === text) return;
+
+		var op = diff(text, value);
+		text = value;
+
+		if (outstanding === null) {
+			outstanding = op;
+			send(op);
+		} else if (buffer === null) {
+			buffer = op;
+		} else {
+			buffer = compose(buffer, op);
+		}
+	}
+
+	function remote(op) {
+		var pair;
+		if (outstanding !== null) {
+			pair = transform(outstanding, op);
+			outstanding = pair[0];
+			op = pair[1];
+			if (buffer !== null) {
+				pair = transform(buffer, op);
+				buffer = pair[0];
+				op = pair[1];
+			}
+		}
+
+		var start = cursor(op, el.selectionStart), end = cursor(op, el.selectionEnd);
+		text = apply(op, text);
+		area.val(text);
+		if (document.activeElement === el) {
+			el.setSelectionRange(start, end);
+		}
+	}
+
+	function users(list) {
+		var html = _.map(list, function(u) {
+			return '<span class="label label-primary">' + _.escape(u.name) + '</span>';
+		});
+		$('#collab-users').html('Editing: ' + html.join(' '));
+	}
+
+	area.on('input', local);
+
+	socket.onmessage = function(e) {
+		var m = JSON.parse(e.data);
+		switch (m.type) {
+		case 'init':
+			revision = m.revision;
+			text = m.text || '';
+			area.val(text).prop('disabled', false);
+			break;
+		case 'ack':
+			revision = m.revision;
+			outstanding = buffer;
+			buffer = null;
+			if (outstanding !== null) send(outstanding);
+			break;
+		case 'op':
+			local();
+			revision = m.revision;
+			remote(m.op);
+			break;
+		case 'presence':
+			users(m.users);
+			break;
+		case 'error':
+			flashError(_.escape(m.message));
+			break;
+		}
+	};
+
+	socket.onclose = function() {
+		area.prop('disabled', true);
+		$('#collab-users').html('<span class="text-danger">Disconnected. Reload the page to keep editing.</span>');
+	};
+});
+// Refresh the notepad list when a note is added, changed, or removed in
+// another window or by another device.
+$(function() {
+	var list = $('#note-list');
+	if (!list.length || !window.EventSource) {
+		return;
+	}
+
+	var refresh = _.debounce(function() {
+		$.get(window.location.href, function(html) {
+			var page = $('<div>').html(html);
+
+			// Keep the selected items selected
+			var selected = $('input[name="id"]:checked', list).map(function() {
+				return this.value;
+			}).get();
+
+			list.html(page.find('#note-list').html());
+			$('#note-pager').html(page.find('#note-pager').html());
+
+			_.each(selected, function(id) {
+				$('input[name="id"][value="' + id + '"]', list).prop('checked', true);
+			});
+		});
+	}, 500);
+
+	var source = new EventSource(list.data('events'));
+	_.each(['note.created', 'note.updated', 'note.deleted'], function(type) {
+		source.addEventListener(type, refresh);
+	});
+});
 function hideFlash(rnum)
 {    
     if (!rnum) rnum = '0';
@@ -46,6 +336,42 @@ function flashWarning(message) {
 	var flash = [{Class: "alert-warning", Message: message}];
 	showFlash(flash);
 }
+// Toggle the pin and favorite flags on a note without reloading the page.
+$(function() {
+	$(document).on('submit', '.toggle-form', function(e) {
+		e.preventDefault();
+
+		var form = $(this);
+		var flag = form.data('flag');
+
+		$.ajax({
+			url: form.attr('action'),
+			method: 'POST',
+			data: form.serialize(),
+			dataType: 'json'
+		}).done(function(data) {
+			var on = data[flag];
+			form.find('input[name="value"]').val(on ? '0' : '1');
+			form.find('button').toggleClass('active', on);
+
+			// Keep the pinned notes at the top of the list
+			if (flag == 'pinned') {
+				var panel = form.closest('.note-panel');
+				panel.toggleClass('pinned', on);
+				if (on) {
+					$('#note-list').prepend(panel);
+				} else {
+					var last = $('#note-list .note-panel.pinned').last();
+					if (last.length) {
+						panel.insertAfter(last);
+					}
+				}
+			}
+		}).fail(function() {
+			flashError('The item could not be updated. Please try again later.');
+		});
+	});
+});
 $(function() {		
 	// Hide any flash messages after a four seconds
 	hideFlash();
@@ -1 +1 @@
-function hideFlash(s){s||(s="0"),_.delay(function(){$(".alert-box-fixed"+s).fadeOut(300,function(){$(this).css({visibility:"hidden",display:"block"}).slideUp();var s=this;_.delay(function(){s.remove()},400)})},4e3)}function showFlash(s){$("#flash-container").html(),$(s).each(function(s,a){var e=_.random(0,1e5),i='<div id="flash-message" class="alert-box-fixed'+e+" alert-box-fixed alert alert-dismissible "+a.Class+'"><button type="button" class="close" data-dismiss="alert" aria-label="Close"><span aria-hidden="true">&times;</span></button>'+a.Message+"</div>";$("#flash-container").prepend(i),hideFlash(e)})}function flashError(s){var a=[{Class:"alert-danger",Message:s}];showFlash(a)}function flashSuccess(s){var a=[{Class:"alert-success",Message:s}];showFlash(a)}function flashNotice(s){var a=[{Class:"alert-info",Message:s}];showFlash(a)}function flashWarning(s){var a=[{Class:"alert-warning",Message:s}];showFlash(a)}$(function(){hideFlash()});
\ No newline at end of file
+$(function(){var area=$('#collab-editor');if(!area.length||!window.WebSocket){return;}function isRetain(c){return typeof c==='number'&&c>0;}function isDelete(c){return typeof c==='number'&&c<0;}function isInsert(c){return typeof c==='string';}function retain(op,n){if(n<=0)return;var l=op.length;if(l&&isRetain(op[l-1]))op[l-1]+=n;else op.push(n);}function remove(op,n){if(n<=0)return;var l=op.length;if(l&&isDelete(op[l-1]))op[l-1]-=n;else op.push(-n);}function insert(op,s){if(!s)return;var l=op.length;if(l&&isInsert(op[l-1])){op[l-1]+=s;}else if(l&&isDelete(op[l-1])){if(l>1&&isInsert(op[l-2])){op[l-2]+=s;}else{op.push(op[l-1]);op[l-1]=s;}}else{op.push(s);}}function apply(op,text){var out=[],i=0;_.each(op,function(c){if(isRetain(c)){out.push(text.slice(i,i+c));i+=c;}else if(isDelete(c)){i-=c;}else{out.push(c);}});return out.join('');}function transform(a,b){var a1=[],b1=[],i=0,j=0,ca=a[i++],cb=b[j++],n;while(ca!==undefined||cb!==undefined){if(isInsert(ca)){insert(a1,ca);retain(b1,ca.length);ca=a[i++];continue;}if(isInsert(cb)){retain(a1,cb.length);insert(b1,cb);cb=b[j++];continue;}n=Math.min(Math.abs(ca),Math.abs(cb));if(isRetain(ca)&&isRetain(cb)){retain(a1,n);retain(b1,n);}else if(isDelete(ca)&&isRetain(cb)){remove(a1,n);}else if(isRetain(ca)&&isDelete(cb)){remove(b1,n);}ca=ca>0?ca-n:ca+n;if(ca===0)ca=a[i++];cb=cb>0?cb-n:cb+n;if(cb===0)cb=b[j++];}return[a1,b1];}function compose(a,b){var op=[],i=0,j=0,ca=a[i++],cb=b[j++],n;while(ca!==undefined||cb!==undefined){if(isDelete(ca)){remove(op,-ca);ca=a[i++];continue;}if(isInsert(cb)){insert(op,cb);cb=b[j++];continue;}if(isInsert(ca)){n=isRetain(cb)?cb:-cb;if(isRetain(cb))insert(op,ca.slice(0,n));if(ca.length>n){ca=ca.slice(n);cb=b[j++];continue;}cb=isRetain(cb)?cb-ca.length:cb+ca.length;ca=a[i++];}else{n=Math.min(ca,Math.abs(cb));if(isRetain(cb))retain(op,n);else remove(op,n);ca-=n;cb=cb>0?cb-n:cb+n;}if(ca===0)ca=a[i++];if(cb===0)cb=b[j++];}return op;}function diff(a,b){var start=0,end=0,op=[];while(start<a.length&&start<b.length&&a[start]===b[start])start++;while(end<a.length-start&&end<b.length-start&&a[a.length-1-end]===b[b.length-1-end])end++;retain(op,start);insert(op,b.slice(start,b.length-end));remove(op,a.length-start-end);retain(op,end);return op;}function cursor(op,pos){var i=0,result=pos;_.each(op,function(c){if(i>=pos)return;if(isRetain(c)){i+=c;}else if(isDelete(c)){result-=Math.min(-c,pos-i);i-=c;}else{result+=c.length;}});return result;}var el=area[0];var text=area.val();var revision=0;var outstanding=null,buffer=null;var url=(location.protocol==='https:'?'wss:':'ws:')+'//'+location.host+area.data('url');var socket=new WebSocket(url);function send(op){socket.send(JSON.stringify({type:'op',revision:revision,op:op}));}function local(){var value=area.val();if(value===text)return;var op=diff(text,value);text=value;if(outstanding===null){outstanding=op;send(op);}else if(buffer===null){buffer=op;}else{buffer=compose(buffer,op);}}function remote(op){var pair;if(outstanding!==null){pair=transform(outstanding,op);outstanding=pair[0];op=pair[1];if(buffer!==null){pair=transform(buffer,op);buffer=pair[0];op=pair[1];}}var start=cursor(op,el.selectionStart),end=cursor(op,el.selectionEnd);text=apply(op,text);area.val(text);if(document.activeElement===el){el.setSelectionRange(start,end);}}function users(list){var html=_.map(list,function(u){return '<span class="label label-primary">'+_.escape(u.name)+'</span>';});$('#collab-users').html('Editing: '+html.join(' '));}area.on('input',local);socket.onmessage=function(e){var m=JSON.parse(e.data);switch(m.type){case 'init':revision=m.revision;text=m.text||'';area.val(text).prop('disabled',false);break;case 'ack':revision=m.revision;outstanding=buffer;buffer=null;if(outstanding!==null)send(outstanding);break;case 'op':local();revision=m.revision;remote(m.op);break;case 'presence':users(m.users);break;case 'error':flashError(_.escape(m.message));break;}};socket.onclose=function(){area.prop('disabled',true);$('#collab-users').html('<span class="text-danger">Disconnected. Reload the page to keep editing.</span>');};});$(function(){var e=$("#note-list");if(e.length&&window.EventSource){var t=_.debounce(function(){$.get(window.location.href,function(t){var n=$("<div>").html(t),i=$('input[name="id"]:checked',e).map(function(){return this.value}).get();e.html(n.find("#note-list").html()),$("#note-pager").html(n.find("#note-pager").html()),_.each(i,function(t){$('input[name="id"][value="'+t+'"]',e).prop("checked",!0)})})},500),n=new EventSource(e.data("events"));_.each(["note.created","note.updated","note.deleted"],function(e){n.addEventListener(e,t)})}});function hideFlash(s){s||(s="0"),_.delay(function(){$(".alert-box-fixed"+s).fadeOut(300,function(){$(this).css({visibility:"hidden",display:"block"}).slideUp();var s=this;_.delay(function(){s.remove()},400)})},4e3)}function showFlash(s){$("#flash-container").html(),$(s).each(function(s,a){var e=_.random(0,1e5),i='<div id="flash-message" class="alert-box-fixed'+e+" alert-box-fixed alert alert-dismissible "+a.Class+'"><button type="button" class="close" data-dismiss="alert" aria-label="Close"><span aria-hidden="true">&times;</span></button>'+a.Message+"</div>";$("#flash-container").prepend(i),hideFlash(e)})}function flashError(s){var a=[{Class:"alert-danger",Message:s}];showFlash(a)}function flashSuccess(s){var a=[{Class:"alert-success",Message:s}];showFlash(a)}function flashNotice(s){var a=[{Class:"alert-info",Message:s}];showFlash(a)}function flashWarning(s){var a=[{Class:"alert-warning",Message:s}];showFlash(a)}$(function(){$(document).on("submit",".toggle-form",function(e){e.preventDefault();var t=$(this),n=t.data("flag");$.ajax({url:t.attr("action"),method:"POST",data:t.serialize(),dataType:"json"}).done(function(e){var a=e[n];if(t.find('input[name="value"]').val(a?"0":"1"),t.find("button").toggleClass("active",a),"pinned"==n){var i=t.closest(".note-panel");if(i.toggleClass("pinned",a),a)$("#note-list").prepend(i);else{var o=$("#note-list .note-panel.pinned").last();o.length&&i.insertAfter(o)}}}).fail(function(){flashError("The item could not be updated. Please try again later.")})})});$(function(){hideFlash()});
\ No newline at end of file
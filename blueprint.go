@@ -2,14 +2,15 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"os"
 	"runtime"
 
 	"github.com/blue-jay/blueprint/lib/boot"
 	"github.com/blue-jay/blueprint/lib/env"
-
-	"github.com/blue-jay/core/router"
-	"github.com/blue-jay/core/server"
+	"github.com/blue-jay/blueprint/lib/graceful"
 )
 
 // init sets runtime settings.
@@ -22,24 +23,49 @@ func init() {
 }
 
 // main loads the configuration file, registers the services, applies the
-// middleware to the router, and then starts the HTTP and HTTPS listeners.
+// middleware to the router, and then starts the HTTP and HTTPS listeners
+// until the application is stopped.
 func main() {
+	// Choose the configuration file
+	configFile := flag.String("config", "env.json", "path to the configuration file")
+	check := flag.Bool("check", false, "check the configuration file and exit")
+	flag.Parse()
+
 	// Load the configuration file
-	config, err := env.LoadConfig("env.json")
+	config, err := env.LoadConfig(*configFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// Check the settings before they are used
+	err = config.Validate()
+	if *check {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(*configFile, "is valid")
+		return
+	}
 	if err != nil {
 		log.Fatalln(err)
 	}
 
 	// Register the services
-	boot.RegisterServices(config)
+	app := boot.RegisterServices(config)
 
 	// Retrieve the middleware
-	handler := boot.SetUpMiddleware(router.Instance())
+	handler := boot.SetUpMiddleware(app, app.Router)
 
-	// Start the HTTP and HTTPS listeners
-	server.Run(
-		handler,       // HTTP handler
-		handler,       // HTTPS handler
-		config.Server, // Server settings
+	// Start the HTTP and HTTPS listeners and stop them on SIGTERM or SIGINT
+	err = graceful.Run(
+		handler,         // HTTP handler
+		handler,         // HTTPS handler
+		config.Server,   // Server settings
+		config.Shutdown, // Shutdown settings
+		app.Stop,        // Shutdown functions
 	)
+	if err != nil {
+		log.Fatalln(err)
+	}
 }
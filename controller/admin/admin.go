@@ -0,0 +1,22 @@
+// Package admin displays the Admin page.
+package admin
+
+import (
+	"net/http"
+
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/route"
+	"github.com/blue-jay/blueprint/middleware/acl"
+)
+
+// Load the routes.
+func Load(r *route.Router) {
+	r.Get("/admin", Index, acl.AllowAdmin)
+}
+
+// Index displays the Admin page.
+func Index(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	c.View.New("admin/index").Render(w, r)
+}
@@ -0,0 +1,122 @@
+// Package api provides the versioned JSON REST API. Requests are authenticated
+// with the session cookie of the website. Requests that make changes must send
+// the CSRF token from the X-CSRF-Token response header in the X-CSRF-Token
+// request header.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/respond"
+	"github.com/blue-jay/blueprint/lib/route"
+
+	"github.com/blue-jay/core/router"
+	"github.com/gorilla/csrf"
+	"github.com/justinas/alice"
+)
+
+var (
+	// prefix is the start of the URI of every resource in this version.
+	prefix = "/api/v1"
+
+	// maxBodySize is the largest request body in bytes.
+	maxBodySize int64 = 1 << 20
+)
+
+// Route is an API route that is registered with the router.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Load the routes.
+func Load(r *route.Router) {
+	load(r.Add)
+}
+
+// Routes returns the API routes. Every route must have an operation in the
+// OpenAPI document.
+func Routes() []Route {
+	var routes []Route
+	load(func(method string, path string, fn http.HandlerFunc, c ...alice.Constructor) {
+		routes = append(routes, Route{Method: method, Path: path})
+	})
+	return routes
+}
+
+// load passes each API route to add so the router and the OpenAPI document
+// have the same routes.
+func load(add func(method string, path string, fn http.HandlerFunc, c ...alice.Constructor)) {
+	add("GET", "/api/openapi.json", OpenAPI)
+
+	c := router.Chain(requireUser)
+	add("GET", noteURI, NoteIndex, c...)
+	add("POST", noteURI, NoteStore, c...)
+	add("GET", noteURI+"/:id", NoteShow, c...)
+	add("PUT", noteURI+"/:id", NoteReplace, c...)
+	add("PATCH", noteURI+"/:id", NoteUpdate, c...)
+	add("DELETE", noteURI+"/:id", NoteDestroy, c...)
+}
+
+// requireUser does not allow anonymous users to access the API and sends the
+// CSRF token with every response.
+func requireUser(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := flight.Context(w, r)
+
+		if c.Sess.Values["id"] == nil {
+			respond.Error(w, http.StatusUnauthorized, "Authentication is required.")
+			return
+		}
+
+		w.Header().Set("X-CSRF-Token", csrf.Token(r))
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// decode reads the JSON request body into v. Writes the error response and
+// returns false if the body cannot be read.
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		respond.Error(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json.")
+		return false
+	}
+
+	d := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	d.DisallowUnknownFields()
+
+	if err := d.Decode(v); err != nil {
+		respond.Error(w, http.StatusBadRequest, fmt.Sprintf("Request body is not valid JSON: %v", err))
+		return false
+	}
+
+	if _, err := d.Token(); err != io.EOF {
+		respond.Error(w, http.StatusBadRequest, "Request body must contain a single JSON object.")
+		return false
+	}
+
+	return true
+}
+
+// intParam returns the query string parameter as an integer between min and
+// max. Returns the default value if the parameter is empty.
+func intParam(r *http.Request, name string, def int, min int, max int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if len(s) == 0 {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil || i < min || i > max {
+		return 0, fmt.Errorf("Parameter %v must be a number from %v to %v.", name, min, max)
+	}
+	return i, nil
+}
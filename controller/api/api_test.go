@@ -0,0 +1,27 @@
+package api_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/blue-jay/blueprint/controller/api"
+)
+
+// TestSpec ensures every registered API route is in the OpenAPI document.
+func TestSpec(t *testing.T) {
+	routes := api.Routes()
+	if len(routes) == 0 {
+		t.Fatal("no routes were registered")
+	}
+
+	d := api.Spec()
+	for _, r := range routes {
+		if !d.Has(r.Method, r.Path) {
+			t.Errorf("route %v %v has no operation in the OpenAPI document", r.Method, r.Path)
+		}
+	}
+
+	if _, err := json.Marshal(d); err != nil {
+		t.Error("could not encode the OpenAPI document:", err)
+	}
+}
@@ -0,0 +1,453 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/noteevent"
+	"github.com/blue-jay/blueprint/lib/respond"
+	"github.com/blue-jay/blueprint/model/note"
+	"github.com/blue-jay/blueprint/model/notebook"
+	"github.com/blue-jay/blueprint/model/tag"
+)
+
+var (
+	// noteURI is the URI of the note collection.
+	noteURI = prefix + "/notes"
+)
+
+// Note is a note in a response.
+type Note struct {
+	ID         uint32     `json:"id"`
+	ExternalID string     `json:"external_id"`
+	Name       string     `json:"name"`
+	Notebook   *string    `json:"notebook"`
+	Tags       []string   `json:"tags"`
+	Pinned     bool       `json:"pinned"`
+	Favorite   bool       `json:"favorite"`
+	Version    uint32     `json:"version"`
+	DueAt      *time.Time `json:"due_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
+
+// NoteList is a list of notes in a response. Meta holds next_cursor for a
+// cursor list and page, per_page, total, and total_pages for a page list.
+type NoteList struct {
+	Data []Note                 `json:"data"`
+	Meta map[string]interface{} `json:"meta"`
+}
+
+// NoteInput is the request body to create or replace a note. Version is
+// required to replace a note and must match the stored version.
+type NoteInput struct {
+	Name     string `json:"name"`
+	Notebook string `json:"notebook"`
+	Pinned   bool   `json:"pinned"`
+	Favorite bool   `json:"favorite"`
+	Version  uint32 `json:"version"`
+}
+
+// NotePatch is the request body to change some fields of a note. Fields that
+// are not sent are not changed. The version is only checked when it is sent.
+type NotePatch struct {
+	Name     *string `json:"name"`
+	Notebook *string `json:"notebook"`
+	Pinned   *bool   `json:"pinned"`
+	Favorite *bool   `json:"favorite"`
+	Version  *uint32 `json:"version"`
+}
+
+// NoteIndex lists the notes. A list is by cursor unless the page parameter is
+// sent.
+func NoteIndex(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	if len(r.URL.Query().Get("page")) > 0 {
+		notePage(w, r, c)
+		return
+	}
+
+	limit, err := intParam(r, "limit", 20, 1, 100)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var after uint64
+	if s := r.URL.Query().Get("cursor"); len(s) > 0 {
+		if after, err = strconv.ParseUint(s, 10, 32); err != nil {
+			respond.Error(w, http.StatusBadRequest, "Parameter cursor is not valid.")
+			return
+		}
+	}
+
+	items, _, err := note.ByUserIDCursor(c.DB, c.UserID, uint32(after), limit)
+	if err != nil {
+		respond.ErrorGeneric(w, err)
+		return
+	}
+
+	list, err := noteList(c.DB, items)
+	if err != nil {
+		respond.ErrorGeneric(w, err)
+		return
+	}
+
+	// There may be more items when the page is full
+	list.Meta["next_cursor"] = nil
+	if len(items) == limit {
+		list.Meta["next_cursor"] = fmt.Sprint(items[len(items)-1].ID)
+	}
+
+	respond.JSON(w, http.StatusOK, list)
+}
+
+// notePage lists the notes by page. The notes can be sorted and filtered the
+// same way as the notepad.
+func notePage(w http.ResponseWriter, r *http.Request, c flight.Info) {
+	page, err := intParam(r, "page", 1, 1, math.MaxInt32)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	perPage, err := intParam(r, "per_page", 20, 1, 100)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f := note.Filter{
+		Sort:     r.URL.Query().Get("sort"),
+		Order:    r.URL.Query().Get("order"),
+		Tag:      r.URL.Query().Get("tag"),
+		Favorite: r.URL.Query().Get("favorite") == "1",
+	}
+
+	items, _, err := note.ByUserIDPaginate(c.DB, c.UserID, f, perPage, (page-1)*perPage)
+	if err != nil {
+		respond.ErrorGeneric(w, err)
+		return
+	}
+
+	count, err := note.ByUserIDCount(c.DB, c.UserID, f)
+	if err != nil {
+		respond.ErrorGeneric(w, err)
+		return
+	}
+
+	list, err := noteList(c.DB, items)
+	if err != nil {
+		respond.ErrorGeneric(w, err)
+		return
+	}
+
+	list.Meta["page"] = page
+	list.Meta["per_page"] = perPage
+	list.Meta["total"] = count
+	list.Meta["total_pages"] = (count + perPage - 1) / perPage
+
+	respond.JSON(w, http.StatusOK, list)
+}
+
+// NoteShow returns a single note.
+func NoteShow(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+	noteResponse(w, c, c.Param("id"), http.StatusOK)
+}
+
+// NoteStore creates a note.
+func NoteStore(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	var in NoteInput
+	if !decode(w, r, &in) {
+		return
+	}
+
+	p := in.patch()
+	if fields := p.validate(); len(fields) > 0 {
+		respond.Invalid(w, fields)
+		return
+	}
+
+	var ID string
+	err := note.Transaction(c.DB, func(tx note.Connection) error {
+		result, err := note.Create(tx, in.Name, c.UserID)
+		if err != nil {
+			return err
+		}
+
+		lastID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		ID = fmt.Sprint(lastID)
+		return p.apply(tx, ID, c.UserID)
+	})
+	if err != nil {
+		respond.ErrorGeneric(w, err)
+		return
+	}
+
+	c.App.Metrics.NotesCreated.Inc()
+	c.App.Events.Publish(c.App.Hub, noteevent.Created, c.UserID, ID)
+
+	w.Header().Set("Location", noteURI+"/"+ID)
+	noteResponse(w, c, ID, http.StatusCreated)
+}
+
+// NoteReplace replaces every field of a note.
+func NoteReplace(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	var in NoteInput
+	if !decode(w, r, &in) {
+		return
+	}
+
+	p := in.patch()
+	fields := p.validate()
+	if in.Version == 0 {
+		fields["version"] = "Version is required."
+	}
+	if len(fields) > 0 {
+		respond.Invalid(w, fields)
+		return
+	}
+
+	noteSave(w, c, p)
+}
+
+// NoteUpdate changes the fields of a note that are in the request body.
+func NoteUpdate(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	var p NotePatch
+	if !decode(w, r, &p) {
+		return
+	}
+
+	if fields := p.validate(); len(fields) > 0 {
+		respond.Invalid(w, fields)
+		return
+	}
+
+	noteSave(w, c, p)
+}
+
+// noteSave stores the changes to a note and writes the note.
+func noteSave(w http.ResponseWriter, c flight.Info, p NotePatch) {
+	ID := c.Param("id")
+
+	item, noRows, err := note.ByID(c.DB, ID, c.UserID)
+	if noRows {
+		respond.Error(w, http.StatusNotFound, "Note could not be found.")
+		return
+	} else if err != nil {
+		respond.ErrorGeneric(w, err)
+		return
+	}
+
+	name := item.Name
+	if p.Name != nil {
+		name = *p.Name
+	}
+
+	version := item.Version
+	if p.Version != nil {
+		version = *p.Version
+	}
+
+	err = note.Transaction(c.DB, func(tx note.Connection) error {
+		if _, err := note.Update(tx, name, ID, c.UserID, fmt.Sprint(version)); err != nil {
+			return err
+		}
+		return p.apply(tx, ID, c.UserID)
+	})
+	if err == note.ErrConflict {
+		respond.Error(w, http.StatusConflict, "Note was changed by another request. Get the note and try again.")
+		return
+	} else if err != nil {
+		respond.ErrorGeneric(w, err)
+		return
+	}
+
+	c.App.Events.Publish(c.App.Hub, noteevent.Updated, c.UserID, ID)
+
+	noteResponse(w, c, ID, http.StatusOK)
+}
+
+// NoteDestroy moves a note to the trash.
+func NoteDestroy(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	result, err := note.DeleteSoft(c.DB, c.Param("id"), c.UserID)
+	if err != nil {
+		respond.ErrorGeneric(w, err)
+		return
+	}
+
+	if rows, err := result.RowsAffected(); err != nil {
+		respond.ErrorGeneric(w, err)
+		return
+	} else if rows == 0 {
+		respond.Error(w, http.StatusNotFound, "Note could not be found.")
+		return
+	}
+
+	c.App.Events.Publish(c.App.Hub, noteevent.Deleted, c.UserID, c.Param("id"))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// noteResponse writes a single note with the status code.
+func noteResponse(w http.ResponseWriter, c flight.Info, ID string, status int) {
+	item, noRows, err := note.ByID(c.DB, ID, c.UserID)
+	if noRows {
+		respond.Error(w, http.StatusNotFound, "Note could not be found.")
+		return
+	} else if err != nil {
+		respond.ErrorGeneric(w, err)
+		return
+	}
+
+	list, err := noteList(c.DB, []note.Item{item})
+	if err != nil {
+		respond.ErrorGeneric(w, err)
+		return
+	}
+
+	respond.JSON(w, status, list.Data[0])
+}
+
+// noteList converts the items to a list with their tags.
+func noteList(db tag.Connection, items []note.Item) (NoteList, error) {
+	IDs := make([]uint32, len(items))
+	for i, item := range items {
+		IDs[i] = item.ID
+	}
+
+	tags, err := tag.ByNoteIDs(db, IDs)
+	if err != nil {
+		return NoteList{}, err
+	}
+
+	m := make(map[uint32][]string)
+	for _, t := range tags {
+		m[t.NoteID] = append(m[t.NoteID], t.Name)
+	}
+
+	list := NoteList{
+		Data: make([]Note, len(items)),
+		Meta: make(map[string]interface{}),
+	}
+
+	for i, item := range items {
+		n := Note{
+			ID:         item.ID,
+			ExternalID: item.ExternalID,
+			Name:       item.Name,
+			Tags:       m[item.ID],
+			Pinned:     item.Pinned,
+			Favorite:   item.Favorite,
+			Version:    item.Version,
+			DueAt:      timePointer(item.DueAt),
+			CreatedAt:  timePointer(item.CreatedAt),
+			UpdatedAt:  timePointer(item.UpdatedAt),
+		}
+		if item.NotebookName.Valid {
+			n.Notebook = &item.NotebookName.String
+		}
+		if n.Tags == nil {
+			n.Tags = []string{}
+		}
+		list.Data[i] = n
+	}
+
+	return list, nil
+}
+
+// patch returns the input as a patch that changes every field.
+func (in NoteInput) patch() NotePatch {
+	p := NotePatch{
+		Name:     &in.Name,
+		Notebook: &in.Notebook,
+		Pinned:   &in.Pinned,
+		Favorite: &in.Favorite,
+	}
+	if in.Version > 0 {
+		p.Version = &in.Version
+	}
+	return p
+}
+
+// validate returns a message for each field that is not valid.
+func (p NotePatch) validate() map[string]string {
+	fields := make(map[string]string)
+
+	if p.Name != nil {
+		switch {
+		case len(strings.TrimSpace(*p.Name)) == 0:
+			fields["name"] = "Name is required."
+		case len(*p.Name) > 65535:
+			fields["name"] = "Name is longer than 65535 bytes."
+		case !utf8.ValidString(*p.Name):
+			fields["name"] = "Name is not valid UTF-8."
+		}
+	}
+
+	if p.Notebook != nil && utf8.RuneCountInString(*p.Notebook) > 100 {
+		fields["notebook"] = "Notebook is longer than 100 characters."
+	}
+
+	return fields
+}
+
+// apply stores the notebook and flags of the patch. The name and version are
+// stored by note.Create and note.Update.
+func (p NotePatch) apply(db note.Connection, ID string, userID string) error {
+	if p.Notebook != nil {
+		var notebookID string
+		if name := strings.TrimSpace(*p.Notebook); len(name) > 0 {
+			var err error
+			if notebookID, err = notebook.FirstOrCreate(db, name, userID); err != nil {
+				return err
+			}
+		}
+		if _, err := note.Move(db, notebookID, ID, userID); err != nil {
+			return err
+		}
+	}
+
+	if p.Pinned != nil {
+		if _, err := note.SetPinned(db, *p.Pinned, ID, userID); err != nil {
+			return err
+		}
+	}
+
+	if p.Favorite != nil {
+		if _, err := note.SetFavorite(db, *p.Favorite, ID, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// timePointer returns the time or nil if it is not set.
+func timePointer(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,202 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/blue-jay/blueprint/controller/api"
+	"github.com/blue-jay/blueprint/lib/env"
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/pubsub"
+	"github.com/blue-jay/blueprint/model/note"
+	"github.com/blue-jay/blueprint/model/user"
+
+	"github.com/blue-jay/core/storage/migration/mysql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	db  *sqlx.DB
+	app *flight.App
+)
+
+// TestMain runs setup, tests, and then teardown.
+func TestMain(m *testing.M) {
+	setup()
+	returnCode := m.Run()
+	teardown()
+	os.Exit(returnCode)
+}
+
+// setup handles any start up tasks. The tests that need the database are
+// skipped when it cannot be reached.
+func setup() {
+	_, conf := mysql.SetUp("../../env.json.example", "database_test")
+
+	// Connect to the database
+	db, _ = conf.Connect(true)
+
+	config, err := env.LoadConfig("../../env.json.example")
+	if err != nil {
+		panic(err)
+	}
+	config.Session.SetupConfig()
+
+	app = flight.NewApp(*config)
+	app.DB = db
+	app.Hub, _ = pubsub.New(pubsub.NewMemory())
+
+	api.Load(app.Router)
+}
+
+// teardown handles any clean up tasks.
+func teardown() {
+	if db != nil {
+		mysql.TearDown(db, "database_test")
+	}
+}
+
+// requireDB skips the test when the database cannot be reached.
+func requireDB(t *testing.T) {
+	if db == nil {
+		t.Skip("database is not available")
+	}
+}
+
+// login returns the session cookie of the user.
+func login(t *testing.T, userID string) *http.Cookie {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	app.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := flight.Context(w, r)
+		c.Sess.Values["id"] = userID
+		if err := c.Sess.Save(r, w); err != nil {
+			t.Fatal("could not save session:", err)
+		}
+	})).ServeHTTP(w, r)
+
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("no session cookie was set")
+	}
+	return cookies[0]
+}
+
+// send makes a request to the API as the user of the cookie and returns the
+// response.
+func send(method string, path string, contentType string, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if len(contentType) > 0 {
+		r.Header.Set("Content-Type", contentType)
+	}
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+
+	w := httptest.NewRecorder()
+	app.Handler(app.Router).ServeHTTP(w, r)
+	return w
+}
+
+// createUser adds a user with an email unique to the test and returns the ID.
+func createUser(t *testing.T, name string) string {
+	email := fmt.Sprintf("%v.%v@domain.com", strings.ToLower(t.Name()), name)
+
+	result, err := user.Create(db, "John", "Doe", email, "p@$$W0rD")
+	if err != nil {
+		t.Fatal("could not create user:", err)
+	}
+
+	ID, err := result.LastInsertId()
+	if err != nil {
+		t.Fatal("could not convert user ID:", err)
+	}
+	return fmt.Sprint(ID)
+}
+
+// createNote adds a note for the user and returns the ID.
+func createNote(t *testing.T, userID string) string {
+	result, err := note.Create(db, "Test data.", userID)
+	if err != nil {
+		t.Fatal("could not create record:", err)
+	}
+
+	ID, err := result.LastInsertId()
+	if err != nil {
+		t.Fatal("could not convert ID:", err)
+	}
+	return fmt.Sprint(ID)
+}
+
+// TestUnauthorized ensures a request without a session is rejected.
+func TestUnauthorized(t *testing.T) {
+	w := send("GET", "/api/v1/notes", "", "", nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("got status %v want %v", w.Code, http.StatusUnauthorized)
+	}
+}
+
+// TestDecode ensures a request body that cannot be read is rejected before
+// anything is stored.
+func TestDecode(t *testing.T) {
+	cookie := login(t, "1")
+
+	for _, tt := range []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"content type", "text/plain", `{"name":"a"}`, http.StatusUnsupportedMediaType},
+		{"unknown field", "application/json", `{"name":"a","color":"red"}`, http.StatusBadRequest},
+		{"trailing data", "application/json", `{"name":"a"} {}`, http.StatusBadRequest},
+		{"not json", "application/json; charset=utf-8", `name=a`, http.StatusBadRequest},
+	} {
+		w := send("POST", "/api/v1/notes", tt.contentType, tt.body, cookie)
+		if w.Code != tt.status {
+			t.Errorf("%v: got status %v want %v: %v", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+}
+
+// TestStaleVersion ensures a note is not replaced with an old version.
+func TestStaleVersion(t *testing.T) {
+	requireDB(t)
+
+	userID := createUser(t, "owner")
+	ID := createNote(t, userID)
+
+	w := send("PUT", "/api/v1/notes/"+ID, "application/json",
+		`{"name":"New test data.","version":99}`, login(t, userID))
+	if w.Code != http.StatusConflict {
+		t.Errorf("got status %v want %v: %v", w.Code, http.StatusConflict, w.Body)
+	}
+}
+
+// TestOtherUser ensures a user cannot read or change the note of another
+// user.
+func TestOtherUser(t *testing.T) {
+	requireDB(t)
+
+	ID := createNote(t, createUser(t, "owner"))
+	cookie := login(t, createUser(t, "other"))
+
+	for _, tt := range []struct {
+		method string
+		body   string
+	}{
+		{"GET", ""},
+		{"PATCH", `{"name":"Changed."}`},
+		{"DELETE", ""},
+	} {
+		w := send(tt.method, "/api/v1/notes/"+ID, "application/json", tt.body, cookie)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%v: got status %v want %v: %v", tt.method, w.Code, http.StatusNotFound, w.Body)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/blue-jay/blueprint/lib/openapi"
+	"github.com/blue-jay/blueprint/lib/respond"
+)
+
+var (
+	spec     *openapi.Document
+	specOnce sync.Once
+)
+
+// OpenAPI returns the OpenAPI document of the registered routes.
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, Spec())
+}
+
+// Spec returns the OpenAPI document with an operation for every registered
+// route that has one. The document is only built once.
+func Spec() *openapi.Document {
+	specOnce.Do(func() {
+		spec = buildSpec()
+	})
+	return spec
+}
+
+// buildSpec builds the OpenAPI document from the registered routes.
+func buildSpec() *openapi.Document {
+	d := openapi.New(openapi.Info{
+		Title:       "Blueprint API",
+		Version:     "1",
+		Description: "Requests are authenticated with the session cookie. Requests that make changes must send the X-CSRF-Token header with the value of the X-CSRF-Token response header.",
+	})
+
+	ops := operations(d)
+	for _, route := range Routes() {
+		if op, ok := ops[route.Method+" "+route.Path]; ok {
+			d.Add(route.Method, route.Path, op)
+		}
+	}
+
+	return d
+}
+
+// operations returns the operation of each route by method and router path.
+func operations(d *openapi.Document) map[string]openapi.Operation {
+	errorBody := func(description string) openapi.Response {
+		return openapi.Response{Description: description, Content: d.JSON(respond.ErrorInfo{})}
+	}
+
+	// Responses shared by the note operations
+	common := func(responses map[string]openapi.Response) map[string]openapi.Response {
+		responses["401"] = errorBody("Authentication is required.")
+		responses["500"] = errorBody("An error occurred on the server.")
+		return responses
+	}
+
+	idParam := openapi.Parameter{Name: "id", In: "path", Required: true, Schema: &openapi.Schema{Type: "integer", Format: "int64"}}
+	note := openapi.Response{Description: "The note.", Content: d.JSON(Note{})}
+	list := openapi.Response{Description: "A list of notes.", Content: d.JSON(NoteList{})}
+	input := &openapi.RequestBody{Required: true, Content: d.JSON(NoteInput{})}
+	patch := &openapi.RequestBody{Required: true, Content: d.JSON(NotePatch{})}
+
+	query := func(name, description string, s *openapi.Schema) openapi.Parameter {
+		return openapi.Parameter{Name: name, In: "query", Description: description, Schema: s}
+	}
+	integer := &openapi.Schema{Type: "integer", Format: "int64"}
+
+	return map[string]openapi.Operation{
+		"GET /api/openapi.json": {
+			OperationID: "getOpenAPI",
+			Summary:     "Get this OpenAPI document.",
+			Tags:        []string{"meta"},
+			Responses: map[string]openapi.Response{
+				"200": {Description: "The OpenAPI document.", Content: map[string]openapi.MediaType{"application/json": {Schema: &openapi.Schema{Type: "object"}}}},
+			},
+		},
+		"GET " + noteURI: {
+			OperationID: "listNotes",
+			Summary:     "List the notes.",
+			Description: "Lists by cursor unless page is sent. Pass next_cursor from the meta as the cursor to get the next list.",
+			Tags:        []string{"notes"},
+			Parameters: []openapi.Parameter{
+				query("cursor", "Get the notes after this cursor.", &openapi.Schema{Type: "string"}),
+				query("limit", "Maximum number of notes from 1 to 100.", integer),
+				query("page", "Page number starting at 1.", integer),
+				query("per_page", "Notes per page from 1 to 100.", integer),
+				query("sort", "Sort of a page.", &openapi.Schema{Type: "string", Enum: []string{"created", "updated", "name"}}),
+				query("order", "Order of a page.", &openapi.Schema{Type: "string", Enum: []string{"asc", "desc"}}),
+				query("tag", "Only notes with this tag on a page.", &openapi.Schema{Type: "string"}),
+				query("favorite", "Only favorites on a page when 1.", &openapi.Schema{Type: "string", Enum: []string{"1"}}),
+			},
+			Responses: common(map[string]openapi.Response{
+				"200": list,
+				"400": errorBody("A parameter is not valid."),
+			}),
+		},
+		"POST " + noteURI: {
+			OperationID: "createNote",
+			Summary:     "Create a note.",
+			Tags:        []string{"notes"},
+			RequestBody: input,
+			Responses: common(map[string]openapi.Response{
+				"201": note,
+				"400": errorBody("Request body is not valid JSON."),
+				"403": errorBody("CSRF token is missing or invalid."),
+				"415": errorBody("Content-Type is not application/json."),
+				"422": errorBody("A field is not valid."),
+			}),
+		},
+		"GET " + noteURI + "/:id": {
+			OperationID: "getNote",
+			Summary:     "Get a note.",
+			Tags:        []string{"notes"},
+			Parameters:  []openapi.Parameter{idParam},
+			Responses: common(map[string]openapi.Response{
+				"200": note,
+				"404": errorBody("Note could not be found."),
+			}),
+		},
+		"PUT " + noteURI + "/:id": {
+			OperationID: "replaceNote",
+			Summary:     "Replace every field of a note.",
+			Tags:        []string{"notes"},
+			Parameters:  []openapi.Parameter{idParam},
+			RequestBody: input,
+			Responses: common(map[string]openapi.Response{
+				"200": note,
+				"400": errorBody("Request body is not valid JSON."),
+				"403": errorBody("CSRF token is missing or invalid."),
+				"404": errorBody("Note could not be found."),
+				"409": errorBody("Version does not match the stored version."),
+				"415": errorBody("Content-Type is not application/json."),
+				"422": errorBody("A field is not valid."),
+			}),
+		},
+		"PATCH " + noteURI + "/:id": {
+			OperationID: "updateNote",
+			Summary:     "Change some fields of a note.",
+			Tags:        []string{"notes"},
+			Parameters:  []openapi.Parameter{idParam},
+			RequestBody: patch,
+			Responses: common(map[string]openapi.Response{
+				"200": note,
+				"400": errorBody("Request body is not valid JSON."),
+				"403": errorBody("CSRF token is missing or invalid."),
+				"404": errorBody("Note could not be found."),
+				"409": errorBody("Version does not match the stored version."),
+				"415": errorBody("Content-Type is not application/json."),
+				"422": errorBody("A field is not valid."),
+			}),
+		},
+		"DELETE " + noteURI + "/:id": {
+			OperationID: "deleteNote",
+			Summary:     "Move a note to the trash.",
+			Tags:        []string{"notes"},
+			Parameters:  []openapi.Parameter{idParam},
+			Responses: common(map[string]openapi.Response{
+				"204": {Description: "The note was moved to the trash."},
+				"403": errorBody("CSRF token is missing or invalid."),
+				"404": errorBody("Note could not be found."),
+			}),
+		},
+	}
+}
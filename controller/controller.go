@@ -3,23 +3,40 @@ package controller
 
 import (
 	"github.com/blue-jay/blueprint/controller/about"
+	"github.com/blue-jay/blueprint/controller/admin"
+	"github.com/blue-jay/blueprint/controller/api"
+	"github.com/blue-jay/blueprint/controller/cron"
 	"github.com/blue-jay/blueprint/controller/debug"
+	"github.com/blue-jay/blueprint/controller/health"
 	"github.com/blue-jay/blueprint/controller/home"
+	"github.com/blue-jay/blueprint/controller/job"
 	"github.com/blue-jay/blueprint/controller/login"
+	"github.com/blue-jay/blueprint/controller/metrics"
 	"github.com/blue-jay/blueprint/controller/notepad"
+	"github.com/blue-jay/blueprint/controller/notetemplate"
 	"github.com/blue-jay/blueprint/controller/register"
 	"github.com/blue-jay/blueprint/controller/static"
 	"github.com/blue-jay/blueprint/controller/status"
+	"github.com/blue-jay/blueprint/controller/webhook"
+	"github.com/blue-jay/blueprint/lib/route"
 )
 
 // LoadRoutes loads the routes for each of the controllers.
-func LoadRoutes() {
-	about.Load()
-	debug.Load()
-	register.Load()
-	login.Load()
-	home.Load()
-	static.Load()
-	status.Load()
-	notepad.Load()
+func LoadRoutes(r *route.Router) {
+	about.Load(r)
+	debug.Load(r)
+	register.Load(r)
+	login.Load(r)
+	home.Load(r)
+	static.Load(r)
+	status.Load(r)
+	notepad.Load(r)
+	notetemplate.Load(r)
+	api.Load(r)
+	webhook.Load(r)
+	admin.Load(r)
+	job.Load(r)
+	cron.Load(r)
+	metrics.Load(r)
+	health.Load(r)
 }
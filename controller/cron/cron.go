@@ -0,0 +1,67 @@
+// Package cron provides the admin page to inspect the recurring tasks and
+// their run history and to run a task now.
+package cron
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/route"
+	"github.com/blue-jay/blueprint/middleware/acl"
+	"github.com/blue-jay/blueprint/model/cronrun"
+	"github.com/blue-jay/blueprint/model/crontask"
+
+	"github.com/blue-jay/core/router"
+)
+
+var (
+	uri = "/admin/cron"
+
+	// limit is the number of runs shown in the history.
+	limit = 50
+)
+
+// Load the routes.
+func Load(r *route.Router) {
+	c := router.Chain(acl.AllowAdmin)
+	r.Get(uri, Index, c...)
+	r.Post(uri+"/run/:name", Run, c...)
+}
+
+// Index displays the tasks and the latest runs.
+func Index(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	tasks, err := crontask.All(c.DB)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		tasks = []crontask.Item{}
+	}
+
+	runs, err := cronrun.Latest(c.DB, limit)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		runs = []cronrun.Item{}
+	}
+
+	v := c.View.New("cron/index")
+	v.Vars["tasks"] = tasks
+	v.Vars["runs"] = runs
+	v.Vars["enabled"] = c.Config.Cron.Enabled
+	v.Render(w, r)
+}
+
+// Run makes a task due now so the next check of any instance runs it.
+func Run(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	_, err := crontask.RunNow(c.DB, c.Param("name"), time.Now())
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	} else {
+		c.FlashSuccess("Task will run on the next check.")
+	}
+
+	c.Redirect(uri)
+}
@@ -5,18 +5,17 @@ import (
 	"net/http"
 	"net/http/pprof"
 
+	"github.com/blue-jay/blueprint/lib/route"
 	"github.com/blue-jay/blueprint/middleware/acl"
 
-	"github.com/blue-jay/core/router"
-
 	"github.com/husobee/vestigo"
 )
 
 // Load the routes.
-func Load() {
+func Load(r *route.Router) {
 	// Enable Pprof
-	router.Get("/debug/pprof/", Index, acl.DisallowAnon)
-	router.Get("/debug/pprof/:pprof", Profile, acl.DisallowAnon)
+	r.Get("/debug/pprof/", Index, acl.DisallowAnon)
+	r.Get("/debug/pprof/:pprof", Profile, acl.DisallowAnon)
 }
 
 // Index shows the profile index.
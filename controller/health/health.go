@@ -0,0 +1,39 @@
+// Package health provides the liveness and readiness checks for a load
+// balancer.
+package health
+
+import (
+	"net/http"
+
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/health"
+	"github.com/blue-jay/blueprint/lib/respond"
+	"github.com/blue-jay/blueprint/lib/route"
+)
+
+// Load the routes.
+func Load(r *route.Router) {
+	r.Get("/healthz", Live)
+	r.Get("/readyz", Ready)
+}
+
+// Live shows the process is running and can serve requests.
+func Live(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, map[string]string{"status": health.OK})
+}
+
+// Ready runs the registered checks and shows the result of each one. The
+// status is 503 when a check fails so the load balancer stops sending
+// requests.
+func Ready(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+	report := c.App.Health.Run(r.Context())
+
+	status := http.StatusOK
+	if report.Status != health.OK {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respond.JSON(w, status, report)
+}
@@ -5,13 +5,12 @@ import (
 	"net/http"
 
 	"github.com/blue-jay/blueprint/lib/flight"
-
-	"github.com/blue-jay/core/router"
+	"github.com/blue-jay/blueprint/lib/route"
 )
 
 // Load the routes.
-func Load() {
-	router.Get("/", Index)
+func Load(r *route.Router) {
+	r.Get("/", Index)
 }
 
 // Index displays the home page.
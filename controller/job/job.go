@@ -0,0 +1,89 @@
+// Package job provides the admin page to inspect the background jobs and to
+// queue dead jobs again.
+package job
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/route"
+	"github.com/blue-jay/blueprint/middleware/acl"
+	"github.com/blue-jay/blueprint/model/job"
+
+	"github.com/blue-jay/core/router"
+)
+
+var (
+	uri = "/admin/job"
+
+	// limit is the number of items shown for each status.
+	limit = 50
+)
+
+// Load the routes.
+func Load(r *route.Router) {
+	c := router.Chain(acl.AllowAdmin)
+	r.Get(uri, Index, c...)
+	r.Post(uri+"/retry/:id", Retry, c...)
+	r.Delete(uri+"/:id", Destroy, c...)
+}
+
+// Index displays the items for each status.
+func Index(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	counts := make(map[string]int)
+	totals, err := job.Counts(c.DB)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	}
+	for _, t := range totals {
+		counts[t.Status] = t.Total
+	}
+
+	v := c.View.New("job/index")
+	for name, status := range map[string]string{
+		"queued":  job.Queued,
+		"running": job.Running,
+		"dead":    job.Dead,
+	} {
+		items, err := job.ByStatus(c.DB, status, limit)
+		if err != nil {
+			c.FlashErrorGeneric(err)
+			items = []job.Item{}
+		}
+		v.Vars[name] = items
+	}
+	v.Vars["counts"] = counts
+	v.Vars["enabled"] = c.Config.Jobs.Enabled
+	v.Render(w, r)
+}
+
+// Retry queues a dead item again.
+func Retry(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	_, err := job.Requeue(c.DB, c.Param("id"), time.Now())
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	} else {
+		c.FlashSuccess("Job queued.")
+	}
+
+	c.Redirect(uri)
+}
+
+// Destroy handles the delete form submission.
+func Destroy(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	_, err := job.Delete(c.DB, c.Param("id"))
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	} else {
+		c.FlashNotice("Job deleted.")
+	}
+
+	c.Redirect(uri)
+}
@@ -5,21 +5,21 @@ import (
 	"net/http"
 
 	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/route"
 	"github.com/blue-jay/blueprint/middleware/acl"
 	"github.com/blue-jay/blueprint/model/user"
 
 	"github.com/blue-jay/core/flash"
 	"github.com/blue-jay/core/form"
 	"github.com/blue-jay/core/passhash"
-	"github.com/blue-jay/core/router"
 	"github.com/blue-jay/core/session"
 )
 
 // Load the routes.
-func Load() {
-	router.Get("/login", Index, acl.DisallowAuth)
-	router.Post("/login", Store, acl.DisallowAuth)
-	router.Get("/logout", Logout)
+func Load(r *route.Router) {
+	r.Get("/login", Index, acl.DisallowAuth)
+	r.Post("/login", Store, acl.DisallowAuth)
+	r.Get("/logout", Logout)
 }
 
 // Index displays the login page.
@@ -50,6 +50,7 @@ func Store(w http.ResponseWriter, r *http.Request) {
 
 	// Determine if user exists
 	if noRows {
+		c.App.Metrics.Logins.WithLabelValues("failure").Inc()
 		c.FlashWarning("Password is incorrect")
 	} else if err != nil {
 		// Display error message
@@ -57,19 +58,23 @@ func Store(w http.ResponseWriter, r *http.Request) {
 	} else if passhash.MatchString(result.Password, password) {
 		if result.StatusID != 1 {
 			// User inactive and display inactive message
+			c.App.Metrics.Logins.WithLabelValues("inactive").Inc()
 			c.FlashNotice("Account is inactive so login is disabled.")
 		} else {
 			// Login successfully
+			c.App.Metrics.Logins.WithLabelValues("success").Inc()
 			session.Empty(c.Sess)
 			c.Sess.AddFlash(flash.Info{"Login successful!", flash.Success})
 			c.Sess.Values["id"] = result.ID
 			c.Sess.Values["email"] = email
 			c.Sess.Values["first_name"] = result.FirstName
+			c.Sess.Values["role_id"] = result.RoleID
 			c.Sess.Save(r, w)
 			http.Redirect(w, r, "/", http.StatusFound)
 			return
 		}
 	} else {
+		c.App.Metrics.Logins.WithLabelValues("failure").Inc()
 		c.FlashWarning("Password is incorrect")
 	}
 
@@ -0,0 +1,39 @@
+// Package metrics serves the metrics of the application in the Prometheus
+// text format.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/blue-jay/blueprint/controller/status"
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/route"
+)
+
+// Load the routes.
+func Load(r *route.Router) {
+	r.Get("/metrics", Index)
+}
+
+// Index writes the metrics when the request has the token or comes from an
+// allowed address.
+func Index(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	if !c.Config.Metrics.Enabled {
+		status.Error404(w, r)
+		return
+	}
+
+	if !c.Config.Metrics.Allowed(r) {
+		if len(c.Config.Metrics.Token) > 0 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	c.App.Metrics.Handler().ServeHTTP(w, r)
+}
@@ -0,0 +1,167 @@
+package notepad
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/collab"
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/noteevent"
+	"github.com/blue-jay/blueprint/lib/pubsub"
+	"github.com/blue-jay/blueprint/model/note"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// upgrader only accepts connections from pages on the same host.
+	upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+
+	// pongWait is how long to wait for a reply to a ping.
+	pongWait = 60 * time.Second
+
+	// pingPeriod is how often a ping is sent.
+	pingPeriod = 30 * time.Second
+
+	// maxMessageSize is the largest message a client can send in bytes.
+	maxMessageSize int64 = 1 << 20
+)
+
+// noteStore loads and saves an item being edited together.
+type noteStore struct {
+	db     note.Connection
+	hub    *pubsub.Hub
+	events *noteevent.Bus
+	ID     string
+	userID string
+}
+
+// Load returns the text and version of the item.
+func (s noteStore) Load() (string, uint32, error) {
+	item, _, err := note.ByID(s.db, s.ID, s.userID)
+	return item.Name, item.Version, err
+}
+
+// Save stores the text if the item has not changed since the version.
+func (s noteStore) Save(text string, version uint32) (uint32, error) {
+	_, err := note.Update(s.db, text, s.ID, s.userID, fmt.Sprint(version))
+	if err != nil {
+		return version, err
+	}
+
+	s.events.Publish(s.hub, noteevent.Updated, s.userID, s.ID)
+	return version + 1, nil
+}
+
+// Live displays the editor to edit an item together with other windows.
+func Live(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	item, _, err := note.ByID(c.DB, c.Param("id"), c.UserID)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		c.Redirect(uri)
+		return
+	}
+
+	v := c.View.New("note/live")
+	v.Vars["item"] = item
+	v.Render(w, r)
+}
+
+// Collab connects the editor to the item with a WebSocket. Only the owner of
+// the item can connect.
+func Collab(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	_, noRows, err := note.ByID(c.DB, c.Param("id"), c.UserID)
+	if noRows {
+		http.Error(w, "Item could not be found.", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Println(err)
+		http.Error(w, "An error occurred on the server.", http.StatusInternalServerError)
+		return
+	}
+
+	hub := c.App.Collab
+	if hub == nil {
+		http.Error(w, "Editing together is not supported.", http.StatusNotImplemented)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already sent the error
+		log.Println(err)
+		return
+	}
+	defer conn.Close()
+
+	store := noteStore{db: c.DB, hub: c.App.Hub, events: c.App.Events, ID: c.Param("id"), userID: c.UserID}
+	doc, client, err := hub.Join(c.UserID+"/"+c.Param("id"), store, fmt.Sprintf("%v", c.Sess.Values["first_name"]))
+	if err != nil {
+		log.Println(err)
+		conn.WriteJSON(collab.Message{Type: "error", Message: "The item could not be opened."})
+		return
+	}
+	defer doc.Leave(client)
+
+	go write(conn, client)
+
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var m collab.Message
+		if err := conn.ReadJSON(&m); err != nil {
+			return
+		}
+
+		if m.Type != "op" {
+			continue
+		}
+
+		if err := doc.Receive(client, m.Revision, m.Op); err != nil {
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()),
+				time.Now().Add(time.Second))
+			return
+		}
+	}
+}
+
+// write sends the messages of the client and a ping on an interval until the
+// client is removed from the document.
+func write(conn *websocket.Conn, client *collab.Client) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	defer conn.Close()
+
+	for {
+		select {
+		case m, ok := <-client.Send:
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := conn.WriteJSON(m); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package notepad
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/noteevent"
+)
+
+var (
+	// heartbeat is how often a comment is sent so proxies do not close an idle
+	// connection.
+	heartbeat = 25 * time.Second
+)
+
+// Events streams the changes to the notes of the user as Server-Sent Events.
+func Events(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	flusher, ok := w.(http.Flusher)
+	hub := c.App.Hub
+	if !ok || hub == nil {
+		http.Error(w, "Streaming is not supported.", http.StatusNotImplemented)
+		return
+	}
+
+	sub := hub.Subscribe(noteevent.Topic(c.UserID))
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Ask the browser to wait a few seconds before reconnecting
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case payload, ok := <-sub.C:
+			if !ok {
+				return
+			}
+
+			var e noteevent.Event
+			if err := json.Unmarshal(payload, &e); err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %v\ndata: %s\n\n", e.Type, payload)
+		}
+		flusher.Flush()
+	}
+}
@@ -2,11 +2,30 @@
 package notepad
 
 import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/jobs"
+	"github.com/blue-jay/blueprint/lib/noteevent"
+	"github.com/blue-jay/blueprint/lib/placeholder"
+	"github.com/blue-jay/blueprint/lib/route"
+	"github.com/blue-jay/blueprint/lib/task"
 	"github.com/blue-jay/blueprint/middleware/acl"
 	"github.com/blue-jay/blueprint/model/note"
+	"github.com/blue-jay/blueprint/model/notebook"
+	"github.com/blue-jay/blueprint/model/notetemplate"
+	"github.com/blue-jay/blueprint/model/tag"
+	"github.com/blue-jay/blueprint/viewfunc/pager"
 
 	"github.com/blue-jay/core/pagination"
 	"github.com/blue-jay/core/router"
@@ -14,18 +33,36 @@ import (
 
 var (
 	uri = "/notepad"
+
+	// dueLayout is the format of the due time in the forms.
+	dueLayout = "2006-01-02T15:04"
+
+	// reminderOptions are the number of minutes before the due time that a
+	// reminder can be sent.
+	reminderOptions = map[string]bool{"0": true, "15": true, "60": true, "1440": true}
 )
 
 // Load the routes.
-func Load() {
+func Load(r *route.Router) {
 	c := router.Chain(acl.DisallowAnon)
-	router.Get(uri, Index, c...)
-	router.Get(uri+"/create", Create, c...)
-	router.Post(uri+"/create", Store, c...)
-	router.Get(uri+"/view/:id", Show, c...)
-	router.Get(uri+"/edit/:id", Edit, c...)
-	router.Patch(uri+"/edit/:id", Update, c...)
-	router.Delete(uri+"/:id", Destroy, c...)
+	r.Get(uri, Index, c...)
+	r.Get(uri+"/create", Create, c...)
+	r.Post(uri+"/create", Store, c...)
+	r.Get(uri+"/view/:id", Show, c...)
+	r.Get(uri+"/edit/:id", Edit, c...)
+	r.Patch(uri+"/edit/:id", Update, c...)
+	r.Get(uri+"/live/:id", Live, c...)
+	r.Get(uri+"/collab/:id", Collab, c...)
+	r.Delete(uri+"/:id", Destroy, c...)
+	r.Get(uri+"/trash", Trash, c...)
+	r.Post(uri+"/trash/empty", EmptyTrash, c...)
+	r.Get(uri+"/events", Events, c...)
+	r.Post(uri+"/bulk", Bulk, c...)
+	r.Post(uri+"/pin/:id", Pin, c...)
+	r.Post(uri+"/favorite/:id", Favorite, c...)
+	r.Get(uri+"/export", Export, c...)
+	r.Get(uri+"/import", Import, c...)
+	r.Post(uri+"/import", ImportStore, c...)
 }
 
 // Index displays the items.
@@ -35,13 +72,27 @@ func Index(w http.ResponseWriter, r *http.Request) {
 	// Create a pagination instance with a max of 10 results.
 	p := pagination.New(r, 10)
 
-	items, _, err := note.ByUserIDPaginate(c.DB, c.UserID, p.PerPage, p.Offset)
+	// Get the sort and filter from the query string.
+	f, query := filter(r)
+
+	// Pinned items are listed first on every page.
+	items, _, err := note.ByUserIDPinned(c.DB, c.UserID, f)
 	if err != nil {
 		c.FlashErrorGeneric(err)
 		items = []note.Item{}
 	}
 
-	count, err := note.ByUserIDCount(c.DB, c.UserID)
+	// The pages only have the items that are not pinned.
+	u := f
+	u.Unpinned = true
+
+	unpinned, _, err := note.ByUserIDPaginate(c.DB, c.UserID, u, p.PerPage, p.Offset)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	}
+	items = append(items, unpinned...)
+
+	count, err := note.ByUserIDCount(c.DB, c.UserID, u)
 	if err != nil {
 		c.FlashErrorGeneric(err)
 	}
@@ -49,18 +100,165 @@ func Index(w http.ResponseWriter, r *http.Request) {
 	// Calculate the number of pages.
 	p.CalculatePages(count)
 
+	notebooks, _, err := notebook.ByUserID(c.DB, c.UserID)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	}
+
+	tags, _, err := tag.ByUserID(c.DB, c.UserID)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	}
+
+	tagNames, err := tagsByNote(c.DB, items)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	}
+
 	v := c.View.New("note/index")
 	v.Vars["items"] = items
+	v.Vars["tags"] = tagNames
+	v.Vars["notebooks"] = notebooks
+	v.Vars["allTags"] = tags
+	v.Vars["query"] = query
+	v.Vars["pager"] = pager.New(query, count, p.PerPage, p.Offset)
+	v.Render(w, r)
+}
+
+// filter returns the sort and filter from the query string along with the
+// values that were accepted so they can be kept in the page links.
+func filter(r *http.Request) (note.Filter, url.Values) {
+	q := r.URL.Query()
+
+	f := note.Filter{
+		Sort:  q.Get("sort"),
+		Order: q.Get("order"),
+		Tag:   q.Get("tag"),
+	}
+
+	query := url.Values{}
+	query.Set("sort", f.SortKey())
+	query.Set("order", f.OrderKey())
+
+	if t, err := time.Parse("2006-01-02", q.Get("from")); err == nil {
+		f.From = t
+		query.Set("from", q.Get("from"))
+	}
+
+	if t, err := time.Parse("2006-01-02", q.Get("to")); err == nil {
+		f.To = t
+		query.Set("to", q.Get("to"))
+	}
+
+	if len(f.Tag) > 0 {
+		query.Set("tag", f.Tag)
+	}
+
+	if q.Get("favorite") == "1" {
+		f.Favorite = true
+		query.Set("favorite", "1")
+	}
+
+	return f, query
+}
+
+// Trash displays the removed items.
+func Trash(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	// Create a pagination instance with a max of 10 results.
+	p := pagination.New(r, 10)
+
+	items, _, err := note.DeletedByUserIDPaginate(c.DB, c.UserID, p.PerPage, p.Offset)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		items = []note.Item{}
+	}
+
+	count, err := note.DeletedByUserIDCount(c.DB, c.UserID)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	}
+
+	// Calculate the number of pages.
+	p.CalculatePages(count)
+
+	tagNames, err := tagsByNote(c.DB, items)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	}
+
+	v := c.View.New("note/trash")
+	v.Vars["items"] = items
+	v.Vars["tags"] = tagNames
 	v.Vars["pagination"] = p
+	v.Vars["canEmpty"] = c.Config.Jobs.Enabled
 	v.Render(w, r)
 }
 
+// EmptyTrash queues a job to remove the items in the trash. The trash cannot
+// be emptied when the job queue is disabled.
+func EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	if !c.Config.Jobs.Enabled {
+		c.FlashError(errors.New("Trash cannot be emptied because background jobs are disabled."))
+		c.Redirect(uri + "/trash")
+		return
+	}
+
+	err := jobs.Enqueue(c.DB, task.PurgeTrash, task.Trash{UserID: c.UserID})
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	} else {
+		c.FlashNotice("Trash will be emptied shortly.")
+	}
+
+	c.Redirect(uri + "/trash")
+}
+
+// tagsByNote returns the tag names for each of the items keyed by item ID.
+func tagsByNote(db tag.Connection, items []note.Item) (map[uint32][]string, error) {
+	IDs := make([]uint32, len(items))
+	for i, item := range items {
+		IDs[i] = item.ID
+	}
+
+	m := make(map[uint32][]string)
+	tags, err := tag.ByNoteIDs(db, IDs)
+	for _, t := range tags {
+		m[t.NoteID] = append(m[t.NoteID], t.Name)
+	}
+	return m, err
+}
+
 // Create displays the create form.
 func Create(w http.ResponseWriter, r *http.Request) {
 	c := flight.Context(w, r)
 
+	templates, _, err := notetemplate.Available(c.DB, c.UserID)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		templates = []notetemplate.Item{}
+	}
+
 	v := c.View.New("note/create")
-	c.Repopulate(v.Vars, "name")
+	v.Vars["templates"] = templates
+	v.Vars["due_at"] = ""
+	v.Vars["reminder"] = ""
+
+	// Start from a template with the placeholders filled in
+	if ID := r.URL.Query().Get("template"); len(ID) > 0 {
+		item, _, err := notetemplate.AvailableByID(c.DB, ID, c.UserID)
+		if err != nil {
+			c.FlashErrorGeneric(err)
+		} else {
+			firstName := fmt.Sprintf("%v", c.Sess.Values["first_name"])
+			v.Vars["name"] = placeholder.Replace(item.Body, placeholder.Values(time.Now(), firstName))
+		}
+	}
+
+	c.Repopulate(v.Vars, "name", "due_at", "reminder")
 	v.Render(w, r)
 }
 
@@ -73,13 +271,40 @@ func Store(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err := note.Create(c.DB, r.FormValue("name"), c.UserID)
+	dueAt, minutes, err := reminder(r)
+	if err != nil {
+		c.FlashError(err)
+		Create(w, r)
+		return
+	}
+
+	result, err := note.Create(c.DB, r.FormValue("name"), c.UserID)
 	if err != nil {
 		c.FlashErrorGeneric(err)
 		Create(w, r)
 		return
 	}
 
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		c.Redirect(uri)
+		return
+	}
+	ID := fmt.Sprint(lastID)
+
+	c.App.Metrics.NotesCreated.Inc()
+	c.App.Events.Publish(c.App.Hub, noteevent.Created, c.UserID, ID)
+
+	if dueAt != nil {
+		_, err = note.SetReminder(c.DB, dueAt, minutes, ID, c.UserID)
+		if err != nil {
+			c.FlashErrorGeneric(err)
+			c.Redirect(uri)
+			return
+		}
+	}
+
 	c.FlashSuccess("Item added.")
 	c.Redirect(uri)
 }
@@ -112,7 +337,15 @@ func Edit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	v := c.View.New("note/edit")
-	c.Repopulate(v.Vars, "name")
+	v.Vars["due_at"] = ""
+	if item.DueAt.Valid {
+		v.Vars["due_at"] = item.DueAt.Time.UTC().Format(dueLayout)
+	}
+	v.Vars["reminder"] = ""
+	if item.RemindBefore.Valid {
+		v.Vars["reminder"] = fmt.Sprint(item.RemindBefore.Int64)
+	}
+	c.Repopulate(v.Vars, "name", "due_at", "reminder")
 	v.Vars["item"] = item
 	v.Render(w, r)
 }
@@ -126,27 +359,273 @@ func Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err := note.Update(c.DB, r.FormValue("name"), c.Param("id"), c.UserID)
+	dueAt, minutes, err := reminder(r)
+	if err != nil {
+		c.FlashError(err)
+		Edit(w, r)
+		return
+	}
+
+	item, _, err := note.ByID(c.DB, c.Param("id"), c.UserID)
 	if err != nil {
 		c.FlashErrorGeneric(err)
 		Edit(w, r)
 		return
 	}
 
+	_, err = note.Update(c.DB, r.FormValue("name"), c.Param("id"), c.UserID, r.FormValue("version"))
+	if err == note.ErrConflict {
+		Conflict(w, r)
+		return
+	} else if err != nil {
+		c.FlashErrorGeneric(err)
+		Edit(w, r)
+		return
+	}
+
+	// Only reset the reminder when the form has it and it changed so it is
+	// not sent twice
+	if hasReminder(r) && reminderChanged(item, dueAt, minutes) {
+		_, err = note.SetReminder(c.DB, dueAt, minutes, c.Param("id"), c.UserID)
+		if err != nil {
+			c.FlashErrorGeneric(err)
+			c.Redirect(uri)
+			return
+		}
+	}
+
+	c.App.Events.Publish(c.App.Hub, noteevent.Updated, c.UserID, c.Param("id"))
+
 	c.FlashSuccess("Item updated.")
 	c.Redirect(uri)
 }
 
+// reminder returns the due time and the number of minutes before the due time
+// to send a reminder from the form. The due time is in UTC.
+func reminder(r *http.Request) (*time.Time, *int, error) {
+	var dueAt *time.Time
+	var minutes *int
+
+	if s := strings.TrimSpace(r.FormValue("due_at")); len(s) > 0 {
+		t, err := time.Parse(dueLayout, s)
+		if err != nil {
+			return nil, nil, errors.New("Due date is not valid.")
+		}
+		dueAt = &t
+	}
+
+	if s := r.FormValue("reminder"); len(s) > 0 {
+		if !reminderOptions[s] {
+			return nil, nil, errors.New("Reminder is not valid.")
+		} else if dueAt == nil {
+			return nil, nil, errors.New("Reminder requires a due date.")
+		}
+		m, _ := strconv.Atoi(s)
+		minutes = &m
+	}
+
+	return dueAt, minutes, nil
+}
+
+// hasReminder returns true if the form has the due time and reminder fields.
+// A form without them does not change the reminder.
+func hasReminder(r *http.Request) bool {
+	_, due := r.Form["due_at"]
+	_, before := r.Form["reminder"]
+	return due || before
+}
+
+// reminderChanged returns true if the due time or reminder is different from
+// the values stored in the item.
+func reminderChanged(item note.Item, dueAt *time.Time, minutes *int) bool {
+	if (dueAt != nil) != item.DueAt.Valid || (minutes != nil) != item.RemindBefore.Valid {
+		return true
+	}
+	if dueAt != nil && !dueAt.Equal(item.DueAt.Time) {
+		return true
+	}
+	return minutes != nil && int64(*minutes) != item.RemindBefore.Int64
+}
+
+// Conflict displays the submitted item next to the item that was saved in
+// the meantime so the changes can be merged.
+func Conflict(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	item, _, err := note.ByID(c.DB, c.Param("id"), c.UserID)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		c.Redirect(uri)
+		return
+	}
+
+	// Render first so the session cookie set by the flash modifier is sent
+	// with the status
+	page := &bufferedWriter{ResponseWriter: w}
+	v := c.View.New("note/conflict")
+	v.Vars["item"] = item
+	v.Vars["mine"] = r.FormValue("name")
+	v.Vars["hasReminder"] = hasReminder(r)
+	v.Vars["due_at"] = r.FormValue("due_at")
+	v.Vars["reminder"] = r.FormValue("reminder")
+	v.Render(page, r)
+
+	w.WriteHeader(http.StatusConflict)
+	page.buf.WriteTo(w)
+}
+
+// bufferedWriter holds the body of a page so the status can be written after
+// the headers are set.
+type bufferedWriter struct {
+	http.ResponseWriter
+	buf bytes.Buffer
+}
+
+// Write adds to the body.
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	return b.buf.Write(p)
+}
+
+// WriteHeader is ignored because the status is written by the caller.
+func (b *bufferedWriter) WriteHeader(int) {}
+
+// Pin handles the pin form submission.
+func Pin(w http.ResponseWriter, r *http.Request) {
+	toggle(w, r, note.SetPinned, "Item pinned.", "Item unpinned.")
+}
+
+// Favorite handles the favorite form submission.
+func Favorite(w http.ResponseWriter, r *http.Request) {
+	toggle(w, r, note.SetFavorite, "Item added to favorites.", "Item removed from favorites.")
+}
+
+// toggle sets a flag on an item from the value in the form. Requests sent by
+// JavaScript get the new flags as JSON so the page does not need to reload.
+func toggle(w http.ResponseWriter, r *http.Request,
+	set func(note.Connection, bool, string, string) (sql.Result, error),
+	on string, off string) {
+	c := flight.Context(w, r)
+
+	value := r.FormValue("value") == "1"
+	_, err := set(c.DB, value, c.Param("id"), c.UserID)
+	if err == nil {
+		c.App.Events.Publish(c.App.Hub, noteevent.Updated, c.UserID, c.Param("id"))
+	}
+
+	if r.Header.Get("X-Requested-With") != "XMLHttpRequest" {
+		if err != nil {
+			c.FlashErrorGeneric(err)
+		} else if value {
+			c.FlashSuccess(on)
+		} else {
+			c.FlashSuccess(off)
+		}
+		c.Redirect(uri)
+		return
+	}
+
+	var item note.Item
+	if err == nil {
+		item, _, err = note.ByID(c.DB, c.Param("id"), c.UserID)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err != nil {
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "An error occurred on the server. Please try again later."})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":       item.ID,
+		"pinned":   item.Pinned,
+		"favorite": item.Favorite,
+	})
+}
+
 // Destroy handles the delete form submission.
 func Destroy(w http.ResponseWriter, r *http.Request) {
 	c := flight.Context(w, r)
 
-	_, err := note.DeleteSoft(c.DB, c.Param("id"), c.UserID)
-	if err != nil {
+	var rows int64
+	result, err := note.DeleteSoft(c.DB, c.Param("id"), c.UserID)
+	if err == nil {
+		rows, err = result.RowsAffected()
+	}
+	switch {
+	case err != nil:
 		c.FlashErrorGeneric(err)
-	} else {
+	case rows == 0:
+		c.FlashWarning("Item could not be found.")
+	default:
+		c.App.Events.Publish(c.App.Hub, noteevent.Deleted, c.UserID, c.Param("id"))
 		c.FlashNotice("Item deleted.")
 	}
 
 	c.Redirect(uri)
 }
+
+// Bulk handles the bulk action form submission.
+func Bulk(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	r.ParseForm()
+	IDs := r.Form["id"]
+	action := r.FormValue("action")
+
+	// Return to the list the form was submitted from
+	back := uri
+	if action == "restore" {
+		back = uri + "/trash"
+	}
+
+	if len(IDs) == 0 {
+		c.FlashWarning("No items selected.")
+		c.Redirect(back)
+		return
+	}
+
+	var count int64
+	var err error
+	var message string
+	event := noteevent.Updated
+
+	switch action {
+	case "delete":
+		count, err = note.BulkDeleteSoft(c.DB, IDs, c.UserID)
+		message = "%v item(s) deleted."
+		event = noteevent.Deleted
+	case "restore":
+		count, err = note.BulkRestore(c.DB, IDs, c.UserID)
+		message = "%v item(s) restored."
+		event = noteevent.Created
+	case "move":
+		if !c.FormValid("notebook") {
+			c.Redirect(back)
+			return
+		}
+		count, err = note.BulkMove(c.DB, IDs, r.FormValue("notebook"), c.UserID)
+		message = "%v item(s) moved."
+	case "tag":
+		if !c.FormValid("tag") {
+			c.Redirect(back)
+			return
+		}
+		count, err = note.BulkTag(c.DB, IDs, r.FormValue("tag"), c.UserID)
+		message = "%v item(s) tagged."
+	default:
+		c.FlashWarning("Action is not valid.")
+		c.Redirect(back)
+		return
+	}
+
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	} else {
+		c.App.Events.Publish(c.App.Hub, event, c.UserID, IDs...)
+		c.FlashNotice(fmt.Sprintf(message, count))
+	}
+
+	c.Redirect(back)
+}
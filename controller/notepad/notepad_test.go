@@ -0,0 +1,153 @@
+package notepad_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blue-jay/blueprint/controller/notepad"
+	"github.com/blue-jay/blueprint/lib/env"
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/pubsub"
+	"github.com/blue-jay/blueprint/model/note"
+	"github.com/blue-jay/blueprint/model/user"
+
+	"github.com/blue-jay/core/storage/migration/mysql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	db  *sqlx.DB
+	app *flight.App
+)
+
+// TestMain runs setup, tests, and then teardown.
+func TestMain(m *testing.M) {
+	setup()
+	returnCode := m.Run()
+	teardown()
+	os.Exit(returnCode)
+}
+
+// setup handles any start up tasks. The tests that need the database are
+// skipped when it cannot be reached.
+func setup() {
+	_, conf := mysql.SetUp("../../env.json.example", "database_test")
+
+	// Connect to the database
+	db, _ = conf.Connect(true)
+
+	config, err := env.LoadConfig("../../env.json.example")
+	if err != nil {
+		panic(err)
+	}
+	config.Session.SetupConfig()
+
+	app = flight.NewApp(*config)
+	app.DB = db
+	app.Hub, _ = pubsub.New(pubsub.NewMemory())
+
+	notepad.Load(app.Router)
+}
+
+// teardown handles any clean up tasks.
+func teardown() {
+	if db != nil {
+		mysql.TearDown(db, "database_test")
+	}
+}
+
+// login returns the session cookie of the user.
+func login(t *testing.T, userID string) *http.Cookie {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	app.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := flight.Context(w, r)
+		c.Sess.Values["id"] = userID
+		if err := c.Sess.Save(r, w); err != nil {
+			t.Fatal("could not save session:", err)
+		}
+	})).ServeHTTP(w, r)
+
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("no session cookie was set")
+	}
+	return cookies[0]
+}
+
+// TestMergeKeepsReminder ensures saving a merge from the conflict page does
+// not remove the due time and the reminder of the note.
+func TestMergeKeepsReminder(t *testing.T) {
+	if db == nil {
+		t.Skip("database is not available")
+	}
+
+	result, err := user.Create(db, "John", "Doe", strings.ToLower(t.Name())+"@domain.com", "p@$$W0rD")
+	if err != nil {
+		t.Fatal("could not create user:", err)
+	}
+	userID, _ := result.LastInsertId()
+	cookie := login(t, fmt.Sprint(userID))
+
+	dueAt := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Minute)
+	minutes := 15
+
+	tests := []struct {
+		name string
+		form url.Values
+	}{
+		{"without the reminder fields", url.Values{}},
+		{"with the submitted reminder fields", url.Values{
+			"due_at":   {dueAt.Format("2006-01-02T15:04")},
+			"reminder": {fmt.Sprint(minutes)},
+		}},
+	}
+
+	for _, tt := range tests {
+		result, err := note.Create(db, "Test data.", fmt.Sprint(userID))
+		if err != nil {
+			t.Fatal("could not create record:", err)
+		}
+		lastID, _ := result.LastInsertId()
+		ID := fmt.Sprint(lastID)
+
+		if _, err := note.SetReminder(db, &dueAt, &minutes, ID, fmt.Sprint(userID)); err != nil {
+			t.Fatal("could not set reminder:", err)
+		}
+
+		form := tt.form
+		form.Set("name", "Merged data.")
+		form.Set("version", "1")
+
+		r := httptest.NewRequest("PATCH", "/notepad/edit/"+ID, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.AddCookie(cookie)
+		w := httptest.NewRecorder()
+		app.Handler(app.Router).ServeHTTP(w, r)
+
+		if w.Code != http.StatusFound {
+			t.Fatalf("%v: got status %v, want %v", tt.name, w.Code, http.StatusFound)
+		}
+
+		item, _, err := note.ByID(db, ID, fmt.Sprint(userID))
+		if err != nil {
+			t.Fatal("could not retrieve record:", err)
+		}
+		if item.Name != "Merged data." {
+			t.Errorf("%v: got name %q, want the merged data", tt.name, item.Name)
+		}
+		if !item.DueAt.Valid || !item.DueAt.Time.Equal(dueAt) {
+			t.Errorf("%v: got due time %v, want %v", tt.name, item.DueAt, dueAt)
+		}
+		if !item.RemindBefore.Valid || item.RemindBefore.Int64 != int64(minutes) {
+			t.Errorf("%v: got reminder %v, want %v", tt.name, item.RemindBefore, minutes)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package notepad
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/noteevent"
+	"github.com/blue-jay/blueprint/lib/notefile"
+	"github.com/blue-jay/blueprint/model/note"
+	"github.com/blue-jay/blueprint/model/notebook"
+	"github.com/blue-jay/blueprint/model/tag"
+)
+
+const (
+	// exportBatch is the number of items read from the database at a time.
+	exportBatch = 100
+	// importMaxBytes is the largest file that can be imported.
+	importMaxBytes = 10 << 20
+)
+
+// Export streams all of the items in the format from the query string.
+func Export(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	format := r.URL.Query().Get("format")
+	fw, err := notefile.NewWriter(w, format)
+	if err != nil {
+		c.FlashWarning("Export format is not supported.")
+		c.Redirect(uri)
+		return
+	}
+
+	w.Header().Set("Content-Type", notefile.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="notes-%v.%v"`,
+		time.Now().Format("20060102"), format))
+
+	// The headers are sent with the first batch so errors are only logged
+	var afterID uint32
+	for {
+		items, _, err := note.ByUserIDCursor(c.DB, c.UserID, afterID, exportBatch)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		tagNames, err := tagsByNote(c.DB, items)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		for _, item := range items {
+			if err := fw.Write(record(item, tagNames[item.ID])); err != nil {
+				log.Println(err)
+				return
+			}
+		}
+
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+
+		afterID = items[len(items)-1].ID
+	}
+
+	if err := fw.Close(); err != nil {
+		log.Println(err)
+	}
+}
+
+// record converts an item to a file record.
+func record(item note.Item, tags []string) notefile.Record {
+	rec := notefile.Record{
+		ExternalID: item.ExternalID,
+		Name:       item.Name,
+		Notebook:   item.NotebookName.String,
+		Tags:       tags,
+	}
+
+	if item.CreatedAt.Valid {
+		rec.CreatedAt = &item.CreatedAt.Time
+	}
+	if item.UpdatedAt.Valid {
+		rec.UpdatedAt = &item.UpdatedAt.Time
+	}
+
+	return rec
+}
+
+// Import displays the import form.
+func Import(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	c.View.New("note/import").Render(w, r)
+}
+
+// ImportStore handles the import form submission. Every valid row is stored
+// in a single transaction and the rows with errors are listed on the page.
+// Rows with an external ID change the existing item so a file can be imported
+// more than once.
+func ImportStore(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	r.Body = http.MaxBytesReader(w, r.Body, importMaxBytes)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		c.FlashWarning("Choose a file to import that is smaller than 10 MB.")
+		Import(w, r)
+		return
+	}
+	defer file.Close()
+
+	// Use the format from the form or from the file extension
+	format := r.FormValue("format")
+	if len(format) == 0 || format == "auto" {
+		format = strings.TrimPrefix(path.Ext(header.Filename), ".")
+		if format == "zip" {
+			format = notefile.MarkdownZIP
+		}
+	}
+
+	if !notefile.Valid(format) {
+		c.FlashWarning("Import format is not supported.")
+		Import(w, r)
+		return
+	}
+
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		Import(w, r)
+		return
+	}
+
+	rows, err := notefile.Read(data, format)
+	if err != nil && len(rows) == 0 {
+		c.FlashError(fmt.Errorf("File could not be read: %v", err))
+		Import(w, r)
+		return
+	}
+
+	var created, updated []string
+	var failed []notefile.Row
+	err = note.Transaction(c.DB, func(tx note.Connection) error {
+		for _, row := range rows {
+			if row.Err != nil {
+				failed = append(failed, row)
+				continue
+			}
+
+			ID, added, err := importRecord(tx, row.Record, c.UserID)
+			if err != nil {
+				return err
+			}
+
+			if added {
+				created = append(created, ID)
+			} else {
+				updated = append(updated, ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		Import(w, r)
+		return
+	}
+
+	c.App.Metrics.NotesCreated.Add(float64(len(created)))
+	c.App.Events.Publish(c.App.Hub, noteevent.Created, c.UserID, created...)
+	c.App.Events.Publish(c.App.Hub, noteevent.Updated, c.UserID, updated...)
+
+	c.FlashSuccess(fmt.Sprintf("%v item(s) added and %v item(s) updated.", len(created), len(updated)))
+
+	v := c.View.New("note/import")
+	v.Vars["failed"] = failed
+	v.Vars["imported"] = true
+	v.Render(w, r)
+}
+
+// importRecord adds or changes an item along with its notebook and tags.
+// Returns the ID of the item and true if the item was added.
+func importRecord(tx note.Connection, rec notefile.Record, userID string) (string, bool, error) {
+	if len(rec.ExternalID) == 0 {
+		rec.ExternalID = note.NewExternalID()
+	}
+
+	var createdAt time.Time
+	if rec.CreatedAt != nil {
+		createdAt = *rec.CreatedAt
+	}
+
+	ID, added, err := note.Upsert(tx, rec.ExternalID, rec.Name, userID, createdAt)
+	if err != nil {
+		return ID, added, err
+	}
+
+	if len(rec.Notebook) > 0 {
+		notebookID, err := notebook.FirstOrCreate(tx, rec.Notebook, userID)
+		if err != nil {
+			return ID, added, err
+		}
+
+		if _, err = note.Move(tx, notebookID, ID, userID); err != nil {
+			return ID, added, err
+		}
+	}
+
+	for _, name := range rec.Tags {
+		tagID, err := tag.FirstOrCreate(tx, name, userID)
+		if err != nil {
+			return ID, added, err
+		}
+
+		if _, err = tag.Attach(tx, tagID, ID, userID); err != nil {
+			return ID, added, err
+		}
+	}
+
+	return ID, added, nil
+}
@@ -0,0 +1,156 @@
+// Package notetemplate provides the pages to manage the templates used to
+// create notes. Users manage their own templates and admins manage the global
+// templates that are available to every user.
+package notetemplate
+
+import (
+	"net/http"
+
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/placeholder"
+	"github.com/blue-jay/blueprint/lib/route"
+	"github.com/blue-jay/blueprint/middleware/acl"
+	"github.com/blue-jay/blueprint/model/notetemplate"
+
+	"github.com/blue-jay/core/router"
+	"github.com/justinas/alice"
+)
+
+var (
+	// mine manages the templates of the current user.
+	mine = scope{uri: "/notepad/template"}
+
+	// global manages the templates that are available to every user.
+	global = scope{uri: "/admin/template", global: true}
+)
+
+// scope is a set of templates and the URI of its pages.
+type scope struct {
+	uri    string
+	global bool
+}
+
+// Load the routes.
+func Load(r *route.Router) {
+	mine.load(r, router.Chain(acl.DisallowAnon)...)
+	global.load(r, router.Chain(acl.AllowAdmin)...)
+}
+
+// load registers the routes of the scope.
+func (s scope) load(r *route.Router, c ...alice.Constructor) {
+	r.Get(s.uri, s.Index, c...)
+	r.Get(s.uri+"/create", s.Create, c...)
+	r.Post(s.uri+"/create", s.Store, c...)
+	r.Get(s.uri+"/edit/:id", s.Edit, c...)
+	r.Patch(s.uri+"/edit/:id", s.Update, c...)
+	r.Delete(s.uri+"/:id", s.Destroy, c...)
+}
+
+// owner returns the user ID the templates belong to. Global templates do not
+// belong to a user.
+func (s scope) owner(c flight.Info) string {
+	if s.global {
+		return ""
+	}
+	return c.UserID
+}
+
+// Index displays the items.
+func (s scope) Index(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	items, _, err := notetemplate.ByUserID(c.DB, s.owner(c))
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		items = []notetemplate.Item{}
+	}
+
+	v := c.View.New("notetemplate/index")
+	v.Vars["items"] = items
+	v.Vars["global"] = s.global
+	v.Render(w, r)
+}
+
+// Create displays the create form.
+func (s scope) Create(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	v := c.View.New("notetemplate/create")
+	v.Vars["global"] = s.global
+	v.Vars["placeholders"] = placeholder.Names()
+	c.Repopulate(v.Vars, "name", "body")
+	v.Render(w, r)
+}
+
+// Store handles the create form submission.
+func (s scope) Store(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	if !c.FormValid("name", "body") {
+		s.Create(w, r)
+		return
+	}
+
+	_, err := notetemplate.Create(c.DB, r.FormValue("name"), r.FormValue("body"), s.owner(c))
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		s.Create(w, r)
+		return
+	}
+
+	c.FlashSuccess("Template added.")
+	c.Redirect(s.uri)
+}
+
+// Edit displays the edit form.
+func (s scope) Edit(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	item, _, err := notetemplate.ByID(c.DB, c.Param("id"), s.owner(c))
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		c.Redirect(s.uri)
+		return
+	}
+
+	v := c.View.New("notetemplate/edit")
+	v.Vars["global"] = s.global
+	v.Vars["placeholders"] = placeholder.Names()
+	c.Repopulate(v.Vars, "name", "body")
+	v.Vars["item"] = item
+	v.Render(w, r)
+}
+
+// Update handles the edit form submission.
+func (s scope) Update(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	if !c.FormValid("name", "body") {
+		s.Edit(w, r)
+		return
+	}
+
+	_, err := notetemplate.Update(c.DB, r.FormValue("name"), r.FormValue("body"), c.Param("id"), s.owner(c))
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		s.Edit(w, r)
+		return
+	}
+
+	c.FlashSuccess("Template updated.")
+	c.Redirect(s.uri)
+}
+
+// Destroy handles the delete form submission.
+func (s scope) Destroy(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	_, err := notetemplate.DeleteSoft(c.DB, c.Param("id"), s.owner(c))
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	} else {
+		c.FlashNotice("Template deleted.")
+	}
+
+	c.Redirect(s.uri)
+}
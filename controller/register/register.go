@@ -2,22 +2,27 @@
 package register
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/hook"
+	"github.com/blue-jay/blueprint/lib/route"
 	"github.com/blue-jay/blueprint/middleware/acl"
 	"github.com/blue-jay/blueprint/model/user"
 
 	"github.com/blue-jay/core/form"
 	"github.com/blue-jay/core/passhash"
-	"github.com/blue-jay/core/router"
 )
 
 // Load the routes.
-func Load() {
-	router.Get("/register", Index, acl.DisallowAuth)
-	router.Post("/register", Store, acl.DisallowAuth)
+func Load(r *route.Router) {
+	r.Get("/register", Index, acl.DisallowAuth)
+	r.Post("/register", Store, acl.DisallowAuth)
 }
 
 // Index displays the register page.
@@ -64,11 +69,13 @@ func Store(w http.ResponseWriter, r *http.Request) {
 	_, noRows, err := user.ByEmail(c.DB, email)
 
 	if noRows { // If success (no user exists with that email)
-		_, err = user.Create(c.DB, firstName, lastName, email, password)
+		result, err := user.Create(c.DB, firstName, lastName, email, password)
 		// Will only error if there is a problem with the query
 		if err != nil {
 			c.FlashErrorGeneric(err)
 		} else {
+			c.App.Metrics.Registrations.Inc()
+			registered(c, result, firstName, lastName, email)
 			c.FlashSuccess("Account created successfully for: " + email)
 			http.Redirect(w, r, "/login", http.StatusFound)
 			return
@@ -82,3 +89,20 @@ func Store(w http.ResponseWriter, r *http.Request) {
 	// Display the page
 	Index(w, r)
 }
+
+// registered stores the webhook deliveries for a new user. Errors are logged
+// because the account was already created.
+func registered(c flight.Info, result sql.Result, firstName, lastName, email string) {
+	ID, err := result.LastInsertId()
+	if err == nil {
+		_, err = c.App.Webhooks.Enqueue(hook.UserRegistered, fmt.Sprint(ID), map[string]string{
+			"id":         fmt.Sprint(ID),
+			"first_name": firstName,
+			"last_name":  lastName,
+			"email":      email,
+		}, time.Now())
+	}
+	if err != nil {
+		log.Println("register:", err)
+	}
+}
@@ -8,14 +8,13 @@ import (
 
 	"github.com/blue-jay/blueprint/controller/status"
 	"github.com/blue-jay/blueprint/lib/flight"
-
-	"github.com/blue-jay/core/router"
+	"github.com/blue-jay/blueprint/lib/route"
 )
 
 // Load the routes.
-func Load() {
+func Load(r *route.Router) {
 	// Serve static files
-	router.Get("/static/*filepath", Index)
+	r.Get("/static/*filepath", Index)
 }
 
 // Index maps static files.
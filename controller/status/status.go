@@ -1,22 +1,30 @@
 // Package status provides all the error pages like 404, 405, 500, 501,
-// and the page when a CSRF token is invalid.
+// and the page when a CSRF token is invalid. Requests for the API get the
+// error as JSON instead of a page.
 package status
 
 import (
 	"net/http"
 
 	"github.com/blue-jay/blueprint/lib/flight"
-	"github.com/blue-jay/core/router"
+	"github.com/blue-jay/blueprint/lib/respond"
+	"github.com/blue-jay/blueprint/lib/route"
+	"github.com/blue-jay/blueprint/middleware/requestid"
 )
 
 // Load the routes.
-func Load() {
-	router.MethodNotAllowed(Error405)
-	router.NotFound(Error404)
+func Load(r *route.Router) {
+	r.MethodNotAllowed(Error405)
+	r.NotFound(Error404)
 }
 
 // Error404 - Page Not Found.
 func Error404(w http.ResponseWriter, r *http.Request) {
+	if respond.IsAPI(r) {
+		respond.Error(w, http.StatusNotFound, "Resource could not be found.")
+		return
+	}
+
 	c := flight.Context(w, r)
 	w.WriteHeader(http.StatusNotFound)
 	v := c.View.New("status/index")
@@ -28,6 +36,12 @@ func Error404(w http.ResponseWriter, r *http.Request) {
 // Error405 - Method Not Allowed.
 func Error405(allowedMethods string) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if respond.IsAPI(r) {
+			w.Header().Set("Allow", allowedMethods)
+			respond.Error(w, http.StatusMethodNotAllowed, "Method is not allowed.")
+			return
+		}
+
 		c := flight.Context(w, r)
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		v := c.View.New("status/index")
@@ -37,18 +51,34 @@ func Error405(allowedMethods string) func(w http.ResponseWriter, r *http.Request
 	}
 }
 
-// Error500 - Internal Server Error.
+// Error500 - Internal Server Error. The request ID is shown so the client can
+// quote it.
 func Error500(w http.ResponseWriter, r *http.Request) {
+	message := "An internal server error occurred."
+	if id := requestid.FromContext(r.Context()); len(id) > 0 {
+		message += " Request ID: " + id
+	}
+
+	if respond.IsAPI(r) {
+		respond.Error(w, http.StatusInternalServerError, message)
+		return
+	}
+
 	c := flight.Context(w, r)
 	w.WriteHeader(http.StatusInternalServerError)
 	v := c.View.New("status/index")
 	v.Vars["title"] = "500 Internal Server Error"
-	v.Vars["message"] = "An internal server error occurred."
+	v.Vars["message"] = message
 	v.Render(w, r)
 }
 
 // Error501 - Not Implemented.
 func Error501(w http.ResponseWriter, r *http.Request) {
+	if respond.IsAPI(r) {
+		respond.Error(w, http.StatusNotImplemented, "Resource is not yet implemented.")
+		return
+	}
+
 	c := flight.Context(w, r)
 	w.WriteHeader(http.StatusNotImplemented)
 	v := c.View.New("status/index")
@@ -59,6 +89,11 @@ func Error501(w http.ResponseWriter, r *http.Request) {
 
 // InvalidToken shows a page in response to CSRF attacks.
 func InvalidToken(w http.ResponseWriter, r *http.Request) {
+	if respond.IsAPI(r) {
+		respond.Error(w, http.StatusForbidden, "CSRF token is missing or invalid. Send the token from the X-CSRF-Token response header.")
+		return
+	}
+
 	c := flight.Context(w, r)
 	w.WriteHeader(http.StatusForbidden)
 	v := c.View.New("status/index")
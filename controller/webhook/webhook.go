@@ -0,0 +1,282 @@
+// Package webhook provides the pages to manage the webhooks that receive the
+// events of the application. Users manage the webhooks for their own events
+// and admins manage the global webhooks that receive the events of every user.
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/hook"
+	"github.com/blue-jay/blueprint/lib/route"
+	"github.com/blue-jay/blueprint/middleware/acl"
+	"github.com/blue-jay/blueprint/model/webhook"
+	"github.com/blue-jay/blueprint/model/webhookdelivery"
+
+	"github.com/blue-jay/core/router"
+	"github.com/justinas/alice"
+)
+
+var (
+	// mine manages the webhooks of the current user.
+	mine = scope{uri: "/webhook"}
+
+	// global manages the webhooks that receive the events of every user.
+	global = scope{uri: "/admin/webhook", global: true}
+
+	// deliveryLimit is the number of deliveries shown in the log.
+	deliveryLimit = 50
+)
+
+// scope is a set of webhooks and the URI of its pages.
+type scope struct {
+	uri    string
+	global bool
+}
+
+// Load the routes.
+func Load(r *route.Router) {
+	mine.load(r, router.Chain(acl.DisallowAnon)...)
+	global.load(r, router.Chain(acl.AllowAdmin)...)
+}
+
+// load registers the routes of the scope.
+func (s scope) load(r *route.Router, c ...alice.Constructor) {
+	r.Get(s.uri, s.Index, c...)
+	r.Get(s.uri+"/create", s.Create, c...)
+	r.Post(s.uri+"/create", s.Store, c...)
+	r.Get(s.uri+"/edit/:id", s.Edit, c...)
+	r.Patch(s.uri+"/edit/:id", s.Update, c...)
+	r.Delete(s.uri+"/:id", s.Destroy, c...)
+	r.Post(s.uri+"/ping/:id", s.Ping, c...)
+	r.Get(s.uri+"/delivery/:id", s.Delivery, c...)
+	r.Post(s.uri+"/delivery/:id", s.Redeliver, c...)
+}
+
+// owner returns the user ID the webhooks belong to. Global webhooks do not
+// belong to a user.
+func (s scope) owner(c flight.Info) string {
+	if s.global {
+		return ""
+	}
+	return c.UserID
+}
+
+// Index displays the items.
+func (s scope) Index(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	items, _, err := webhook.ByUserID(c.DB, s.owner(c))
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		items = []webhook.Item{}
+	}
+
+	v := c.View.New("webhook/index")
+	v.Vars["items"] = items
+	v.Vars["global"] = s.global
+	v.Vars["enabled"] = c.App.Webhooks.Enabled()
+	v.Render(w, r)
+}
+
+// Create displays the create form.
+func (s scope) Create(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	v := c.View.New("webhook/create")
+	v.Vars["global"] = s.global
+	v.Vars["events"] = hook.Events
+	v.Vars["selected"] = selected(r.Form["events"])
+	c.Repopulate(v.Vars, "url")
+	v.Render(w, r)
+}
+
+// Store handles the create form submission.
+func (s scope) Store(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	events, err := validate(r)
+	if err != nil {
+		c.FlashError(err)
+		s.Create(w, r)
+		return
+	}
+
+	secret, err := hook.NewSecret()
+	if err == nil {
+		_, err = webhook.Create(c.DB, r.FormValue("url"), secret, events, s.owner(c))
+	}
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		s.Create(w, r)
+		return
+	}
+
+	c.FlashSuccess("Webhook added.")
+	c.Redirect(s.uri)
+}
+
+// Edit displays the edit form.
+func (s scope) Edit(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	item, _, err := webhook.ByID(c.DB, c.Param("id"), s.owner(c))
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		c.Redirect(s.uri)
+		return
+	}
+
+	v := c.View.New("webhook/edit")
+	v.Vars["global"] = s.global
+	v.Vars["events"] = hook.Events
+	if r.Method == http.MethodGet {
+		v.Vars["selected"] = selected(strings.Split(item.Events, ","))
+	} else {
+		v.Vars["selected"] = selected(r.Form["events"])
+	}
+	c.Repopulate(v.Vars, "url")
+	v.Vars["item"] = item
+	v.Render(w, r)
+}
+
+// Update handles the edit form submission.
+func (s scope) Update(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	events, err := validate(r)
+	if err != nil {
+		c.FlashError(err)
+		s.Edit(w, r)
+		return
+	}
+
+	_, err = webhook.Update(c.DB, r.FormValue("url"), events, c.Param("id"), s.owner(c))
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		s.Edit(w, r)
+		return
+	}
+
+	c.FlashSuccess("Webhook updated.")
+	c.Redirect(s.uri)
+}
+
+// Destroy handles the delete form submission.
+func (s scope) Destroy(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	_, err := webhook.DeleteSoft(c.DB, c.Param("id"), s.owner(c))
+	if err != nil {
+		c.FlashErrorGeneric(err)
+	} else {
+		c.FlashNotice("Webhook deleted.")
+	}
+
+	c.Redirect(s.uri)
+}
+
+// Ping sends a test event to the webhook.
+func (s scope) Ping(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	item, _, err := webhook.ByID(c.DB, c.Param("id"), s.owner(c))
+	if err == nil {
+		err = c.App.Webhooks.EnqueuePing(item.ID, time.Now())
+	}
+	if err == hook.ErrDisabled {
+		c.FlashError(errors.New("Webhooks are disabled."))
+		c.Redirect(s.uri)
+		return
+	} else if err != nil {
+		c.FlashErrorGeneric(err)
+		c.Redirect(s.uri)
+		return
+	}
+
+	c.FlashSuccess("Ping queued.")
+	c.Redirect(s.uri + "/delivery/" + c.Param("id"))
+}
+
+// Delivery displays the latest deliveries of the webhook.
+func (s scope) Delivery(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	item, _, err := webhook.ByID(c.DB, c.Param("id"), s.owner(c))
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		c.Redirect(s.uri)
+		return
+	}
+
+	deliveries, err := webhookdelivery.ByWebhookID(c.DB, item.ID, deliveryLimit)
+	if err != nil {
+		c.FlashErrorGeneric(err)
+		deliveries = []webhookdelivery.Item{}
+	}
+
+	v := c.View.New("webhook/delivery")
+	v.Vars["global"] = s.global
+	v.Vars["item"] = item
+	v.Vars["deliveries"] = deliveries
+	v.Render(w, r)
+}
+
+// Redeliver sends a delivery of the webhook again.
+func (s scope) Redeliver(w http.ResponseWriter, r *http.Request) {
+	c := flight.Context(w, r)
+
+	var queued bool
+	item, _, err := webhook.ByID(c.DB, c.Param("id"), s.owner(c))
+	if err == nil {
+		queued, err = c.App.Webhooks.Redeliver(r.FormValue("delivery"), item.ID, time.Now())
+	}
+	switch {
+	case err == hook.ErrDisabled:
+		c.FlashError(errors.New("Webhooks are disabled."))
+	case err != nil:
+		c.FlashErrorGeneric(err)
+	case !queued:
+		c.FlashNotice("Delivery is already queued.")
+	default:
+		c.FlashSuccess("Delivery queued.")
+	}
+
+	c.Redirect(s.uri + "/delivery/" + c.Param("id"))
+}
+
+// validate returns the events from the form as a comma separated list or an
+// error if the URL or the events are not valid.
+func validate(r *http.Request) (string, error) {
+	u, err := url.Parse(r.FormValue("url"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return "", errors.New("URL must start with http:// or https://.")
+	}
+
+	events := r.Form["events"]
+	if len(events) == 0 {
+		return "", errors.New("Choose at least one event.")
+	}
+
+	known := selected(hook.Events)
+	for _, e := range events {
+		if !known[e] {
+			return "", errors.New("Event is not supported: " + e)
+		}
+	}
+
+	return strings.Join(events, ","), nil
+}
+
+// selected returns a set of the events.
+func selected(events []string) map[string]bool {
+	m := make(map[string]bool)
+	for _, e := range events {
+		m[e] = true
+	}
+	return m
+}
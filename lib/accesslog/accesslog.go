@@ -0,0 +1,207 @@
+// Package accesslog writes a structured line for each request to the
+// application as JSON or logfmt. Paths can be excluded or sampled and the
+// lines can be written to a file that is rotated by size.
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// JSON writes each line as a JSON object.
+	JSON = "json"
+	// Logfmt writes each line as key=value pairs.
+	Logfmt = "logfmt"
+)
+
+// Info holds the details for the access log.
+type Info struct {
+	// Format is json or logfmt. The default is logfmt.
+	Format string `json:"Format"`
+	// File is the path of the log file. The log is written to stdout when it
+	// is empty.
+	File string `json:"File"`
+	// MaxSize is the size in megabytes of the log file before it is rotated.
+	MaxSize int `json:"MaxSize"`
+	// MaxBackups is the number of rotated files to keep.
+	MaxBackups int `json:"MaxBackups"`
+	// Exclude is a list of path prefixes that are not logged.
+	Exclude []string `json:"Exclude"`
+	// Sample maps path prefixes to the fraction of requests that are logged,
+	// between 0 and 1. The longest matching prefix is used. Responses with a
+	// server error status are always logged.
+	Sample map[string]float64 `json:"Sample"`
+}
+
+// Entry is a single request.
+type Entry struct {
+	Time       time.Time
+	Method     string
+	Path       string
+	Status     int
+	Bytes      int64
+	Latency    time.Duration
+	RemoteAddr string
+	UserID     string
+	RequestID  string
+	UserAgent  string
+}
+
+// Logger writes the entries.
+type Logger struct {
+	info        Info
+	out         io.Writer
+	mutex       sync.Mutex
+	filterMutex sync.RWMutex
+}
+
+// New returns a logger for the settings. The log file is opened or created.
+func New(info Info) (*Logger, error) {
+	if info.Format != JSON {
+		info.Format = Logfmt
+	}
+
+	l := &Logger{info: info, out: os.Stdout}
+	if len(info.File) > 0 {
+		f, err := NewRotatingFile(info.File, int64(info.MaxSize)<<20, info.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		l.out = f
+	}
+
+	return l, nil
+}
+
+// NewWriter returns a logger for the settings that writes to w instead of the
+// file or stdout.
+func NewWriter(w io.Writer, info Info) *Logger {
+	if info.Format != JSON {
+		info.Format = Logfmt
+	}
+	return &Logger{info: info, out: w}
+}
+
+// SetFilters changes the excluded and sampled paths while requests are
+// logged.
+func (l *Logger) SetFilters(exclude []string, sample map[string]float64) {
+	l.filterMutex.Lock()
+	l.info.Exclude = exclude
+	l.info.Sample = sample
+	l.filterMutex.Unlock()
+}
+
+// Skip returns true if requests to the path are never logged.
+func (l *Logger) Skip(path string) bool {
+	l.filterMutex.RLock()
+	defer l.filterMutex.RUnlock()
+
+	for _, prefix := range l.info.Exclude {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sampled returns true if a request to the path with the status is logged.
+func (l *Logger) Sampled(path string, status int) bool {
+	l.filterMutex.RLock()
+	defer l.filterMutex.RUnlock()
+
+	if status >= 500 || len(l.info.Sample) == 0 {
+		return true
+	}
+
+	rate, longest := 1.0, -1
+	for prefix, r := range l.info.Sample {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			rate, longest = r, len(prefix)
+		}
+	}
+
+	return rate >= 1 || rand.Float64() < rate
+}
+
+// Log writes the entry. Errors are ignored so logging never fails a request.
+func (l *Logger) Log(e Entry) {
+	b := l.Format(e)
+
+	l.mutex.Lock()
+	l.out.Write(b)
+	l.mutex.Unlock()
+}
+
+// Format returns the line for the entry.
+func (l *Logger) Format(e Entry) []byte {
+	fields := []struct {
+		key   string
+		value interface{}
+	}{
+		{"time", e.Time.UTC().Format(time.RFC3339Nano)},
+		{"method", e.Method},
+		{"path", e.Path},
+		{"status", e.Status},
+		{"bytes", e.Bytes},
+		{"latency_ms", float64(e.Latency.Microseconds()) / 1000},
+		{"remote_addr", e.RemoteAddr},
+		{"user_id", e.UserID},
+		{"request_id", e.RequestID},
+		{"user_agent", e.UserAgent},
+	}
+
+	var buf bytes.Buffer
+	if l.info.Format == JSON {
+		buf.WriteByte('{')
+		for i, f := range fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(f.key)
+			v, _ := json.Marshal(f.value)
+			buf.Write(k)
+			buf.WriteByte(':')
+			buf.Write(v)
+		}
+		buf.WriteString("}\n")
+		return buf.Bytes()
+	}
+
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(f.key)
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(f.value))
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// Close closes the log file.
+func (l *Logger) Close() error {
+	if c, ok := l.out.(io.Closer); ok && l.out != os.Stdout {
+		return c.Close()
+	}
+	return nil
+}
+
+// logfmtValue returns the value quoted when it is empty or has a space, a
+// quote, or an equals sign.
+func logfmtValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if len(s) == 0 || strings.ContainsAny(s, " \"=\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,173 @@
+package accesslog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/accesslog"
+)
+
+var entry = accesslog.Entry{
+	Time:       time.Date(2016, 6, 30, 2, 5, 0, 0, time.UTC),
+	Method:     "GET",
+	Path:       "/notepad?page=2",
+	Status:     200,
+	Bytes:      512,
+	Latency:    1500 * time.Microsecond,
+	RemoteAddr: "127.0.0.1:5000",
+	UserID:     "1",
+	RequestID:  "abc",
+	UserAgent:  "Mozilla/5.0 (X11)",
+}
+
+// TestLogfmt ensures values with spaces or equals signs are quoted.
+func TestLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+	accesslog.NewWriter(&buf, accesslog.Info{}).Log(entry)
+
+	expected := `time=2016-06-30T02:05:00Z method=GET path="/notepad?page=2" status=200 bytes=512 latency_ms=1.5 ` +
+		`remote_addr=127.0.0.1:5000 user_id=1 request_id=abc user_agent="Mozilla/5.0 (X11)"` + "\n"
+	if buf.String() != expected {
+		t.Errorf("\n got: %v\nwant: %v", buf.String(), expected)
+	}
+}
+
+// TestJSON ensures each line is a JSON object.
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	accesslog.NewWriter(&buf, accesslog.Info{Format: accesslog.JSON}).Log(entry)
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["status"] != 200.0 || m["user_id"] != "1" || m["latency_ms"] != 1.5 {
+		t.Errorf("got %v", m)
+	}
+}
+
+// TestSkip ensures excluded paths are skipped and sampled paths are dropped
+// unless the response is a server error.
+func TestSkip(t *testing.T) {
+	l := accesslog.NewWriter(ioutil.Discard, accesslog.Info{
+		Exclude: []string{"/static/"},
+		Sample:  map[string]float64{"/": 1, "/notepad/events": 0},
+	})
+
+	if !l.Skip("/static/js/all.js") || l.Skip("/notepad") {
+		t.Error("exclude does not match the path prefix")
+	}
+	if !l.Sampled("/notepad", 200) {
+		t.Error("expected /notepad to be logged")
+	}
+	if l.Sampled("/notepad/events", 200) {
+		t.Error("expected /notepad/events to be dropped")
+	}
+	if !l.Sampled("/notepad/events", 500) {
+		t.Error("expected a server error to be logged")
+	}
+}
+
+// TestRotatingFile ensures the file is rotated when it is full and only the
+// maximum number of rotated files are kept.
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accesslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "access.log")
+	f, err := accesslog.NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Close()
+
+	for name, expected := range map[string]string{
+		"access.log":   "dddddddd\n",
+		"access.log.1": "cccccccc\n",
+		"access.log.2": "bbbbbbbb\n",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+		} else if string(b) != expected {
+			t.Errorf("%v: got %q, want %q", name, b, expected)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("expected only two rotated files")
+	}
+}
+
+// TestRotateFailed ensures the file is still written when it cannot be
+// renamed.
+func TestRotateFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accesslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A folder that is not empty cannot be replaced by the rename
+	path := filepath.Join(dir, "access.log")
+	if err := os.MkdirAll(filepath.Join(path+".1", "keep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := accesslog.NewRotatingFile(path, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Close()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "aaaaaaaa\nbbbbbbbb\ncccccccc\n"; string(b) != expected {
+		t.Errorf("got %q, want %q", b, expected)
+	}
+}
+
+// TestSetFilters ensures the filters can change while requests are logged.
+func TestSetFilters(t *testing.T) {
+	l := accesslog.NewWriter(ioutil.Discard, accesslog.Info{Exclude: []string{"/static/"}})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			l.Skip("/static/app.css")
+			l.Sampled("/api/v1/note", 200)
+		}
+	}()
+	l.SetFilters([]string{"/healthz"}, map[string]float64{"/api/": 0})
+	<-done
+
+	if l.Skip("/static/app.css") || !l.Skip("/healthz") {
+		t.Error("exclude list was not changed")
+	}
+	if l.Sampled("/api/v1/note", 200) {
+		t.Error("sample rate was not changed")
+	}
+}
@@ -0,0 +1,106 @@
+package accesslog
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// RotatingFile is a file that is renamed with a number suffix when it grows
+// past a maximum size. The newest rotated file ends with .1.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	mutex      sync.Mutex
+}
+
+// NewRotatingFile opens or creates the file at the path. A maxSize of zero or
+// less means the file is never rotated. A maxBackups of zero or less keeps
+// one rotated file.
+func NewRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	if maxBackups <= 0 {
+		maxBackups = 1
+	}
+
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes to the file and rotates it first if the write would make it
+// larger than the maximum size.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.file.Close()
+}
+
+// open opens the file for appending.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate renames the current and rotated files and opens a new file. The
+// oldest rotated file is removed. When the current file cannot be renamed it
+// is opened again so the log keeps working, and the rotation is tried again
+// after another maxSize bytes.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	os.Remove(fmt.Sprintf("%v.%v", f.path, f.maxBackups))
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%v.%v", f.path, i), fmt.Sprintf("%v.%v", f.path, i+1))
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		log.Println("accesslog: could not rotate the file:", err)
+		if err := f.open(); err != nil {
+			return err
+		}
+		f.size = 0
+		return nil
+	}
+
+	return f.open()
+}
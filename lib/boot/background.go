@@ -0,0 +1,53 @@
+package boot
+
+import (
+	"github.com/blue-jay/blueprint/lib/cron"
+	"github.com/blue-jay/blueprint/lib/env"
+	"github.com/blue-jay/blueprint/lib/hook"
+	"github.com/blue-jay/blueprint/lib/jobs"
+	"github.com/blue-jay/blueprint/lib/reminder"
+)
+
+// background holds the services that run in the background so they can be
+// started and stopped when their Enabled setting is reloaded.
+type background struct {
+	queue     *jobs.Queue
+	scheduler *cron.Scheduler
+	reminders *reminder.Scheduler
+	webhooks  *hook.Dispatcher
+}
+
+// apply starts the services that are enabled and stops the others. The
+// services that put jobs in the queue are stopped before the queue and
+// started after it. Starting or stopping a service twice does nothing.
+func (b background) apply(c env.Info) error {
+	if !c.Reminder.Enabled {
+		b.reminders.Stop()
+	}
+	if !c.Webhook.Enabled {
+		b.webhooks.Stop()
+	}
+	if !c.Cron.Enabled {
+		b.scheduler.Stop()
+	}
+	if !c.Jobs.Enabled {
+		b.queue.Stop()
+	}
+
+	if c.Jobs.Enabled {
+		b.queue.Start()
+	}
+	if c.Cron.Enabled {
+		if err := b.scheduler.Start(); err != nil {
+			return err
+		}
+	}
+	if c.Webhook.Enabled {
+		b.webhooks.Start()
+	}
+	if c.Reminder.Enabled {
+		b.reminders.Start()
+	}
+
+	return nil
+}
@@ -2,13 +2,24 @@
 package boot
 
 import (
+	"html/template"
 	"log"
+	"time"
 
 	"github.com/blue-jay/blueprint/controller"
+	"github.com/blue-jay/blueprint/lib/accesslog"
+	"github.com/blue-jay/blueprint/lib/collab"
+	"github.com/blue-jay/blueprint/lib/cron"
 	"github.com/blue-jay/blueprint/lib/env"
 	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/hook"
+	"github.com/blue-jay/blueprint/lib/jobs"
+	"github.com/blue-jay/blueprint/lib/pubsub"
+	"github.com/blue-jay/blueprint/lib/reminder"
+	"github.com/blue-jay/blueprint/lib/task"
 	"github.com/blue-jay/blueprint/viewfunc/link"
 	"github.com/blue-jay/blueprint/viewfunc/noescape"
+	"github.com/blue-jay/blueprint/viewfunc/pager"
 	"github.com/blue-jay/blueprint/viewfunc/prettytime"
 	"github.com/blue-jay/blueprint/viewmodify/authlevel"
 	"github.com/blue-jay/blueprint/viewmodify/flash"
@@ -19,32 +30,35 @@ import (
 	"github.com/blue-jay/core/xsrf"
 )
 
-// RegisterServices sets up all the web components.
-func RegisterServices(config *env.Info) {
+// RegisterServices sets up all the web components and returns the
+// application that holds them.
+func RegisterServices(config *env.Info) *flight.App {
 	// Set up the session cookie store
 	err := config.Session.SetupConfig()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Connect to the MySQL database
-	mysqlDB, _ := config.MySQL.Connect(true)
-
-	// Load the controller routes
-	controller.LoadRoutes()
+	// Connect to the MySQL or PostgreSQL database
+	db, dbName, migrations, err := connect(config)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Set up the views
 	config.View.SetTemplates(config.Template.Root, config.Template.Children)
 
 	// Set up the functions for the views
-	config.View.SetFuncMaps(
+	funcMaps := []template.FuncMap{
 		config.Asset.Map(config.View.BaseURI),
 		link.Map(config.View.BaseURI),
 		noescape.Map(),
 		prettytime.Map(),
+		pager.Map(),
 		form.Map(),
 		pagination.Map(),
-	)
+	}
+	config.View.SetFuncMaps(funcMaps...)
 
 	// Set up the variables and modifiers for the views
 	config.View.SetModifiers(
@@ -54,15 +68,100 @@ func RegisterServices(config *env.Info) {
 		flash.Modify,
 	)
 
-	// Store the variables in flight
-	flight.StoreConfig(*config)
+	// Store the variables and the database connection in the application
+	app := flight.NewApp(*config)
+	app.DB = db
 
-	// Store the database connection in flight
-	flight.StoreDB(mysqlDB)
+	// Close the database last so the other services can finish their work
+	app.Stop.OnShutdown(func() {
+		if err := db.Close(); err != nil {
+			log.Println(err)
+		}
+	})
+
+	// Load the controller routes
+	controller.LoadRoutes(app.Router)
+
+	// Record the connection pool stats of the database
+	if err := app.Metrics.RegisterDB(db.DB, dbName); err != nil {
+		log.Println("metrics:", err)
+	}
+
+	// Check the database, the migrations, and the views for readiness
+	registerChecks(app.Health, config, db, migrations, funcMaps)
+
+	// Queue the note reminders
+	reminders := reminder.New(db, config.Reminder)
+	app.Stop.OnShutdown(reminders.Stop)
+
+	// Run the background jobs
+	queue := jobs.New(db, config.Jobs)
+	task.Register(queue, db, config.Email)
+	app.Stop.OnShutdown(queue.Stop)
+
+	// Queue the webhook deliveries for the events and send them with the jobs
+	dispatcher := hook.New(db, config.Webhook)
+	dispatcher.Register(queue)
+	app.Events.Handle(dispatcher.NoteHandler())
+	app.Webhooks = dispatcher
+
+	// Run the recurring tasks
+	scheduler := cron.New(db, config.Cron)
+	registerTasks(scheduler, db)
+	app.Stop.OnShutdown(scheduler.Stop)
+
+	// Start the background services that are enabled
+	services := background{
+		queue:     queue,
+		scheduler: scheduler,
+		reminders: reminders,
+		webhooks:  dispatcher,
+	}
+	if err := services.apply(*config); err != nil {
+		log.Fatal(err)
+	}
+
+	// Set up the hub for the real-time events. Use a backend that is shared
+	// between instances when running more than one instance.
+	hub, err := pubsub.New(pubsub.NewMemory())
+	if err != nil {
+		log.Fatal(err)
+	}
+	app.Hub = hub
+
+	// Save the notes being edited together every five seconds
+	collabHub := collab.NewHub(5 * time.Second)
+	app.Collab = collabHub
+
+	// End the event streams and save the shared notes when the server stops
+	// so the requests in flight can finish
+	app.Stop.OnDrain(func() {
+		if err := hub.Close(); err != nil {
+			log.Println(err)
+		}
+	})
+	app.Stop.OnDrain(collabHub.Close)
+
+	// Open the access log
+	accessLog, err := accesslog.New(config.AccessLog)
+	if err != nil {
+		log.Fatal(err)
+	}
+	app.AccessLog = accessLog
+	app.Stop.OnShutdown(func() {
+		if err := accessLog.Close(); err != nil {
+			log.Println(err)
+		}
+	})
 
 	// Store the csrf information
-	flight.StoreXsrf(xsrf.Info{
+	app.Xsrf = xsrf.Info{
 		AuthKey: config.Session.CSRFKey,
 		Secure:  config.Session.Options.Secure,
-	})
+	}
+
+	// Apply the changes to the configuration file without a restart
+	watchConfig(app, services)
+
+	return app
 }
@@ -0,0 +1,46 @@
+package boot
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/cron"
+	"github.com/blue-jay/blueprint/model/cronrun"
+	"github.com/blue-jay/blueprint/model/note"
+	"github.com/blue-jay/blueprint/model/webhookdelivery"
+)
+
+// retention is how long removed notes and old history are kept.
+const retention = 30 * 24 * time.Hour
+
+// registerTasks adds the recurring tasks to the scheduler.
+func registerTasks(s *cron.Scheduler, db cron.Connection) {
+	tasks := []struct {
+		name       string
+		expression string
+		fn         func(context.Context) error
+	}{
+		// Remove the notes that have been in the trash for a while
+		{"note.purge", "@daily", func(ctx context.Context) error {
+			_, err := note.PurgeDeletedBefore(db, time.Now().Add(-retention))
+			return err
+		}},
+		// Remove the old webhook deliveries
+		{"webhook.prune", "30 0 * * *", func(ctx context.Context) error {
+			_, err := webhookdelivery.DeleteBefore(db, time.Now().Add(-retention))
+			return err
+		}},
+		// Remove the old run history
+		{"cron.prune", "45 0 * * *", func(ctx context.Context) error {
+			_, err := cronrun.DeleteBefore(db, time.Now().Add(-retention))
+			return err
+		}},
+	}
+
+	for _, t := range tasks {
+		if err := s.Register(t.name, t.expression, t.fn); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
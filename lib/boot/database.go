@@ -0,0 +1,22 @@
+package boot
+
+import (
+	"github.com/blue-jay/blueprint/lib/database"
+	"github.com/blue-jay/blueprint/lib/env"
+
+	"github.com/blue-jay/core/storage/migration"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// connect opens the database chosen in the settings and returns it with the
+// name of the database and the settings of its migrations.
+func connect(config *env.Info) (*sqlx.DB, string, migration.Info, error) {
+	if config.Database.Type == database.PostgreSQL {
+		db, err := config.PostgreSQL.Connect(true)
+		return db, config.PostgreSQL.Database, config.PostgreSQL.Migration, err
+	}
+
+	db, err := config.MySQL.Connect(true)
+	return db, config.MySQL.Database, config.MySQL.Migration, err
+}
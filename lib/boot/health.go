@@ -0,0 +1,25 @@
+package boot
+
+import (
+	"html/template"
+
+	"github.com/blue-jay/blueprint/lib/env"
+	"github.com/blue-jay/blueprint/lib/health"
+
+	"github.com/blue-jay/core/storage/migration"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// registerChecks adds the readiness checks of the application. Other
+// subsystems can add their own with Register.
+func registerChecks(checks *health.Checks, config *env.Info, db *sqlx.DB, migrations migration.Info, funcMaps []template.FuncMap) {
+	// The database responds
+	checks.Register("database", health.Ping(db))
+
+	// Every migration in the folder was applied
+	checks.Register("migrations", health.Migrations(db, migrations))
+
+	// Every view parses with the functions from the views
+	checks.Register("templates", health.Templates(config.View.Folder, config.View.Extension, funcMaps...))
+}
@@ -4,19 +4,34 @@ package boot
 import (
 	"net/http"
 
+	"github.com/blue-jay/blueprint/controller/status"
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/middleware/instrument"
 	"github.com/blue-jay/blueprint/middleware/logrequest"
+	"github.com/blue-jay/blueprint/middleware/recovery"
+	"github.com/blue-jay/blueprint/middleware/requestid"
 	"github.com/blue-jay/blueprint/middleware/rest"
 	"github.com/blue-jay/core/router"
 	"github.com/gorilla/context"
 )
 
 // SetUpMiddleware contains the middleware that applies to every request.
-func SetUpMiddleware(h http.Handler) http.Handler {
+func SetUpMiddleware(app *flight.App, h http.Handler) http.Handler {
+	// Count the panics that are recovered. Pass more reporters to send them
+	// to an error reporting service.
+	recoverPanic := recovery.New(status.Error500, func(recovery.Panic) {
+		app.Metrics.Panics.Inc()
+	})
+
 	return router.ChainHandler( // Chain middleware, top middleware runs first
-		h,                    // Handler to wrap
-		setUpCSRF,            // Prevent CSRF
-		rest.Handler,         // Support changing HTTP method sent via query string
-		logrequest.Handler,   // Log every request
-		context.ClearHandler, // Prevent memory leak with gorilla.sessions
+		h,                             // Handler to wrap
+		context.ClearHandler,          // Prevent memory leak with gorilla.sessions
+		app.Handler,                   // Give every request the application
+		requestid.Handler,             // Give every request an ID
+		logrequest.New(app.AccessLog), // Log every request
+		instrument.New(app.Metrics),   // Record the request metrics
+		recoverPanic,                  // Show an error page on a panic
+		setUpCSRF(app.Xsrf),           // Prevent CSRF
+		rest.Handler,                  // Support changing HTTP method sent via query string
 	)
 }
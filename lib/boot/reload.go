@@ -0,0 +1,108 @@
+package boot
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/env"
+	"github.com/blue-jay/blueprint/lib/flight"
+)
+
+// configPoll is how often the configuration file is checked for changes.
+const configPoll = 2 * time.Second
+
+// fileState is what changes when the configuration file is saved.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// stat returns the state of the file or the zero value when it cannot be
+// read.
+func stat(path string) fileState {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{fi.ModTime(), fi.Size()}
+}
+
+// watchConfig reloads the configuration file when it changes or on SIGHUP
+// until the application stops. SIGHUP hands off the sockets instead when
+// that is enabled. The background services are started or stopped when their
+// Enabled setting changes.
+func watchConfig(app *flight.App, services background) {
+	current := app.Config()
+
+	signals := make(chan os.Signal, 1)
+	if !current.Shutdown.Handoff {
+		signal.Notify(signals, syscall.SIGHUP)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	app.Stop.OnShutdown(func() {
+		close(stop)
+		<-done
+	})
+
+	go func() {
+		defer close(done)
+		defer signal.Stop(signals)
+
+		ticker := time.NewTicker(configPoll)
+		defer ticker.Stop()
+
+		last := stat(current.Path())
+		for {
+			select {
+			case <-stop:
+				return
+			case <-signals:
+			case <-ticker.C:
+				state := stat(current.Path())
+				if state == last {
+					continue
+				}
+				last = state
+			}
+
+			current = reloadConfig(app, services, current)
+		}
+	}()
+}
+
+// reloadConfig loads the configuration file and stores the reloadable changes
+// when the settings are valid. The changes that need a restart are logged.
+// Returns the settings in use.
+func reloadConfig(app *flight.App, services background, current env.Info) env.Info {
+	next, err := env.LoadConfig(current.Path())
+	if err == nil {
+		err = next.Validate()
+	}
+	if err != nil {
+		log.Println("env: the settings were not reloaded:", err)
+		return current
+	}
+
+	updated, applied, restart := env.Reload(current, *next)
+	for _, path := range restart {
+		log.Printf("env: %v changed but needs a restart to apply", path)
+	}
+	if len(applied) == 0 {
+		return current
+	}
+
+	app.StoreConfig(updated)
+	app.AccessLog.SetFilters(updated.AccessLog.Exclude, updated.AccessLog.Sample)
+	if err := services.apply(updated); err != nil {
+		log.Println("cron:", err)
+	}
+	log.Println("env: reloaded", strings.Join(applied, ", "))
+
+	return updated
+}
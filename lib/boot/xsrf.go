@@ -7,14 +7,12 @@ import (
 	"net/http"
 
 	"github.com/blue-jay/blueprint/controller/status"
-	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/core/xsrf"
 	"github.com/gorilla/csrf"
 )
 
 // setUpCSRF sets up the CSRF protection.
-func setUpCSRF(h http.Handler) http.Handler {
-	x := flight.Xsrf()
-
+func setUpCSRF(x xsrf.Info) func(http.Handler) http.Handler {
 	// Decode the string
 	key, err := base64.StdEncoding.DecodeString(x.AuthKey)
 	if err != nil {
@@ -26,6 +24,6 @@ func setUpCSRF(h http.Handler) http.Handler {
 		csrf.ErrorHandler(http.HandlerFunc(status.InvalidToken)),
 		csrf.FieldName("_token"),
 		csrf.Secure(x.Secure),
-	)(h)
+	)
 	return cs
 }
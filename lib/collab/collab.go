@@ -0,0 +1,349 @@
+// Package collab lets several clients edit the same text at the same time.
+// Changes are merged on the server with operational transform and saved with
+// a store on an interval and when the last client leaves.
+package collab
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/ot"
+)
+
+const (
+	// maxHistory is the number of operations kept to transform the operations
+	// of clients that are behind.
+	maxHistory = 1000
+
+	// sendBuffer is the number of messages a client can fall behind before it
+	// is disconnected.
+	sendBuffer = 64
+)
+
+var (
+	// ErrRevision is returned when an operation is based on a revision that is
+	// not known.
+	ErrRevision = errors.New("revision is not known, reload the document")
+)
+
+// Store loads and saves the text of a document. Save returns the new version
+// of the text.
+type Store interface {
+	Load() (text string, version uint32, err error)
+	Save(text string, version uint32) (uint32, error)
+}
+
+// User is a person editing a document. The ID is different for every
+// connection so the same person can edit from two windows.
+type User struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Message is sent between the server and the clients.
+//
+// The server sends init with the text and revision when a client joins, ack
+// when an operation of the client is applied, op with the operations of the
+// other clients, presence with the users editing the document, and error
+// before the connection is closed. A client sends op with the revision the
+// operation is based on.
+type Message struct {
+	Type     string `json:"type"`
+	Revision int    `json:"revision"`
+	Op       ot.Op  `json:"op,omitempty"`
+	Text     string `json:"text,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Users    []User `json:"users,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Client is a connection to a document. Messages for the client are sent on
+// Send which is closed when the client is removed.
+type Client struct {
+	User User
+	Send <-chan Message
+	send chan Message
+}
+
+// Document is a text that is being edited.
+type Document struct {
+	key      string
+	hub      *Hub
+	store    Store
+	text     string
+	version  uint32
+	revision int
+	history  []ot.Op
+	clients  map[*Client]bool
+	dirty    bool
+	stop     chan struct{}
+	mutex    sync.Mutex
+}
+
+// Hub holds the documents that are being edited.
+type Hub struct {
+	interval time.Duration
+	docs     map[string]*Document
+	mutex    sync.Mutex
+}
+
+// NewHub returns a hub that saves the documents on the interval.
+func NewHub(interval time.Duration) *Hub {
+	return &Hub{
+		interval: interval,
+		docs:     make(map[string]*Document),
+	}
+}
+
+// Join adds a client with the name of the user to the document with the key.
+// The document is loaded with the store if no one is editing it. The hub is
+// not locked while the document loads so other documents are not blocked.
+func (h *Hub) Join(key string, store Store, name string) (*Document, *Client, error) {
+	h.mutex.Lock()
+	d, ok := h.docs[key]
+	h.mutex.Unlock()
+
+	if !ok {
+		text, version, err := store.Load()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Another client may have loaded the document in the meantime
+		h.mutex.Lock()
+		if d, ok = h.docs[key]; !ok {
+			d = &Document{
+				key:     key,
+				hub:     h,
+				store:   store,
+				text:    text,
+				version: version,
+				clients: make(map[*Client]bool),
+				stop:    make(chan struct{}),
+			}
+			h.docs[key] = d
+			go d.saveLoop(h.interval)
+		}
+		h.mutex.Unlock()
+	}
+
+	send := make(chan Message, sendBuffer)
+	c := &Client{User: User{ID: newID(), Name: name}, Send: send, send: send}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	// The document may have been closed after it was found
+	if d.clients == nil {
+		return nil, nil, errors.New("document was closed, try again")
+	}
+
+	d.clients[c] = true
+	c.send <- Message{
+		Type:     "init",
+		Revision: d.revision,
+		Text:     d.text,
+		ClientID: c.User.ID,
+	}
+	d.presence()
+
+	return d, c, nil
+}
+
+// Close saves and closes every document.
+func (h *Hub) Close() {
+	h.mutex.Lock()
+	docs := make([]*Document, 0, len(h.docs))
+	for _, d := range h.docs {
+		docs = append(docs, d)
+	}
+	h.mutex.Unlock()
+
+	for _, d := range docs {
+		d.mutex.Lock()
+		d.close("The server is restarting. Reload to continue.")
+		d.mutex.Unlock()
+	}
+}
+
+// Leave removes a client from the document. The document is saved and closed
+// when the last client leaves.
+func (d *Document) Leave(c *Client) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !d.clients[c] {
+		return
+	}
+
+	delete(d.clients, c)
+	close(c.send)
+
+	if len(d.clients) == 0 {
+		d.close("")
+		return
+	}
+
+	d.presence()
+}
+
+// Receive applies an operation the client made at the revision. The operation
+// is transformed against the operations the client has not seen yet.
+func (d *Document) Receive(c *Client, revision int, op ot.Op) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !d.clients[c] {
+		return errors.New("client is not editing the document")
+	}
+
+	base := d.revision - len(d.history)
+	if revision < base || revision > d.revision {
+		return ErrRevision
+	}
+
+	var err error
+	for _, h := range d.history[revision-base:] {
+		if op, _, err = ot.Transform(op, h); err != nil {
+			return err
+		}
+	}
+
+	text, err := op.Apply(d.text)
+	if err != nil {
+		return err
+	}
+
+	d.text = text
+	d.revision++
+	d.history = append(d.history, op)
+	if len(d.history) > maxHistory {
+		d.history = d.history[len(d.history)-maxHistory:]
+	}
+	d.dirty = true
+
+	for other := range d.clients {
+		if other == c {
+			d.deliver(other, Message{Type: "ack", Revision: d.revision})
+		} else {
+			d.deliver(other, Message{Type: "op", Revision: d.revision, Op: op, ClientID: c.User.ID})
+		}
+	}
+
+	return nil
+}
+
+// presence sends the users editing the document to every client. The mutex
+// must be held.
+func (d *Document) presence() {
+	users := make([]User, 0, len(d.clients))
+	for c := range d.clients {
+		users = append(users, c.User)
+	}
+
+	for c := range d.clients {
+		d.deliver(c, Message{Type: "presence", Revision: d.revision, Users: users})
+	}
+}
+
+// deliver sends a message to a client without blocking. A client that is too
+// far behind is removed. The mutex must be held.
+func (d *Document) deliver(c *Client, m Message) {
+	select {
+	case c.send <- m:
+	default:
+		log.Println("collab: removed slow client from", d.key)
+		delete(d.clients, c)
+		close(c.send)
+	}
+}
+
+// saveLoop saves the document on the interval until it is closed.
+func (d *Document) saveLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.mutex.Lock()
+			if err := d.save(); err != nil {
+				log.Println("collab:", err)
+				d.disconnect("The note was changed outside of the editor. Reload to continue.")
+			}
+			d.mutex.Unlock()
+		}
+	}
+}
+
+// save stores the text if it changed. The mutex must be held.
+func (d *Document) save() error {
+	if !d.dirty {
+		return nil
+	}
+
+	version, err := d.store.Save(d.text, d.version)
+	if err != nil {
+		return err
+	}
+
+	d.version = version
+	d.dirty = false
+	return nil
+}
+
+// close saves the document and disconnects the clients. The mutex must be
+// held.
+func (d *Document) close(message string) {
+	if d.clients == nil {
+		return
+	}
+
+	if err := d.save(); err != nil {
+		log.Println("collab:", err)
+		if len(message) == 0 {
+			message = "Your changes could not be saved. Reload to continue."
+		}
+	}
+
+	d.disconnect(message)
+}
+
+// disconnect sends the message to the clients if it is not empty, disconnects
+// them, and removes the document from the hub without saving. The mutex must
+// be held.
+func (d *Document) disconnect(message string) {
+	if d.clients == nil {
+		return
+	}
+
+	for c := range d.clients {
+		if len(message) > 0 {
+			d.deliver(c, Message{Type: "error", Message: message})
+		}
+		if d.clients[c] {
+			close(c.send)
+		}
+	}
+	d.clients = nil
+	close(d.stop)
+
+	d.hub.mutex.Lock()
+	if d.hub.docs[d.key] == d {
+		delete(d.hub.docs, d.key)
+	}
+	d.hub.mutex.Unlock()
+}
+
+// newID returns a random ID for a client.
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
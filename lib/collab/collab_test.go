@@ -0,0 +1,188 @@
+package collab_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/collab"
+	"github.com/blue-jay/blueprint/lib/ot"
+)
+
+// store keeps the text in memory.
+type store struct {
+	text    string
+	version uint32
+	saves   int
+}
+
+func (s *store) Load() (string, uint32, error) {
+	return s.text, s.version, nil
+}
+
+func (s *store) Save(text string, version uint32) (uint32, error) {
+	s.text = text
+	s.version = version + 1
+	s.saves++
+	return s.version, nil
+}
+
+// receive returns the next message of the type.
+func receive(t *testing.T, c *collab.Client, messageType string) collab.Message {
+	for {
+		select {
+		case m, ok := <-c.Send:
+			if !ok {
+				t.Fatalf("channel closed while waiting for %v", messageType)
+			}
+			if m.Type == messageType {
+				return m
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %v", messageType)
+		}
+	}
+}
+
+// TestConcurrentEdits ensures two clients that edit at the same revision end
+// up with the same text and the text is saved when they leave.
+func TestConcurrentEdits(t *testing.T) {
+	s := &store{text: "Hello world", version: 1}
+	h := collab.NewHub(time.Hour)
+
+	d, a, err := h.Join("1", s, "Ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, b, err := h.Join("1", s, "Bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	init := receive(t, b, "init")
+	if init.Text != "Hello world" || init.Revision != 0 {
+		t.Fatalf("wrong init: %+v", init)
+	}
+
+	presence := receive(t, b, "presence")
+	if len(presence.Users) != 2 {
+		t.Errorf("got %v users want 2", len(presence.Users))
+	}
+
+	// Both edit revision 0
+	if err := d.Receive(a, 0, ot.Op{}.Insert("Oh, ").Retain(11)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Receive(b, 0, ot.Op{}.Retain(11).Insert("!")); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := receive(t, a, "op"); m.Revision != 2 {
+		t.Errorf("wrong revision: %v", m.Revision)
+	}
+	if m := receive(t, b, "ack"); m.Revision != 2 {
+		t.Errorf("wrong revision: %v", m.Revision)
+	}
+
+	if err := d.Receive(a, 5, ot.Op{}.Retain(16)); err != collab.ErrRevision {
+		t.Errorf("got %v want %v", err, collab.ErrRevision)
+	}
+
+	d.Leave(a)
+	d.Leave(b)
+
+	expected := "Oh, Hello world!"
+	if s.text != expected {
+		t.Errorf("\n got: %v\nwant: %v", s.text, expected)
+	}
+	if s.saves != 1 || s.version != 2 {
+		t.Errorf("got %v saves and version %v want 1 and 2", s.saves, s.version)
+	}
+
+	// The document is loaded again after everyone left
+	_, c, err := h.Join("1", s, "Ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := receive(t, c, "init"); m.Text != expected {
+		t.Errorf("\n got: %v\nwant: %v", m.Text, expected)
+	}
+	h.Close()
+}
+
+// slowStore waits for release before the text is loaded.
+type slowStore struct {
+	store
+	release chan struct{}
+}
+
+func (s *slowStore) Load() (string, uint32, error) {
+	<-s.release
+	return s.store.Load()
+}
+
+// TestSlowLoad ensures a document that is loading does not block the other
+// documents.
+func TestSlowLoad(t *testing.T) {
+	slow := &slowStore{release: make(chan struct{})}
+	h := collab.NewHub(time.Hour)
+	defer h.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, _, err := h.Join("1", slow, "Ann"); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	joined := make(chan error)
+	go func() {
+		_, _, err := h.Join("2", &store{}, "Bob")
+		joined <- err
+	}()
+
+	select {
+	case err := <-joined:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("join was blocked by a document that is loading")
+	}
+
+	close(slow.release)
+	<-done
+}
+
+// failStore cannot save the text.
+type failStore struct {
+	store
+}
+
+func (s *failStore) Save(text string, version uint32) (uint32, error) {
+	s.saves++
+	return 0, errors.New("version does not match")
+}
+
+// TestSaveFailed ensures the clients are disconnected without saving again
+// when the save on the interval fails.
+func TestSaveFailed(t *testing.T) {
+	s := &failStore{store{text: "Hello", version: 1}}
+	h := collab.NewHub(10 * time.Millisecond)
+
+	d, a, err := h.Join("1", s, "Ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Receive(a, 0, ot.Op{}.Retain(5).Insert("!")); err != nil {
+		t.Fatal(err)
+	}
+
+	receive(t, a, "error")
+	d.Leave(a)
+
+	if s.saves != 1 {
+		t.Errorf("got %v saves want 1", s.saves)
+	}
+}
@@ -0,0 +1,237 @@
+// Package cron runs recurring tasks on cron schedules. The tasks are stored
+// in the cron_task table and each run is locked in the database so only one
+// instance of the application runs a task at a time. The result of every run
+// is stored in the cron_run table. Schedules are in UTC.
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/blue-jay/blueprint/model/cronrun"
+	"github.com/blue-jay/blueprint/model/crontask"
+)
+
+// Info holds the details for the scheduler.
+type Info struct {
+	Enabled bool `json:"Enabled"`
+	// Interval is the number of seconds between checks for due tasks.
+	Interval int `json:"Interval"`
+	// LockTimeout is the number of seconds a task can run. The lock of a task
+	// that runs longer expires so another instance can run it.
+	LockTimeout int `json:"LockTimeout"`
+}
+
+// Connection is an interface for making queries.
+type Connection interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
+}
+
+// Task is a function that runs on a schedule.
+type Task struct {
+	Name       string
+	Expression string
+	Schedule   Schedule
+	Run        func(context.Context) error
+}
+
+// Scheduler runs the registered tasks when they are due.
+type Scheduler struct {
+	db     Connection
+	info   Info
+	owner  string
+	tasks  map[string]Task
+	mutex  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New returns a scheduler that is not started.
+func New(db Connection, info Info) *Scheduler {
+	if info.Interval <= 0 {
+		info.Interval = 30
+	}
+	if info.LockTimeout <= 0 {
+		info.LockTimeout = 3600
+	}
+
+	host, _ := os.Hostname()
+
+	return &Scheduler{
+		db:    db,
+		info:  info,
+		owner: fmt.Sprintf("%v:%v", host, os.Getpid()),
+		tasks: make(map[string]Task),
+	}
+}
+
+// Register adds a task that runs on the schedule of the cron expression. It
+// must be called before Start. Returns an error if the expression is not
+// valid.
+func (s *Scheduler) Register(name string, expression string, fn func(context.Context) error) error {
+	schedule, err := Parse(expression)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.tasks[name] = Task{
+		Name:       name,
+		Expression: expression,
+		Schedule:   schedule,
+		Run:        fn,
+	}
+	s.mutex.Unlock()
+	return nil
+}
+
+// Tasks returns the registered tasks sorted by name.
+func (s *Scheduler) Tasks() []Task {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tasks := make([]Task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		tasks = append(tasks, t)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Name < tasks[j].Name
+	})
+	return tasks
+}
+
+// Start stores the registered tasks and checks for due tasks in the
+// background until Stop is called.
+func (s *Scheduler) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.cancel != nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	for _, t := range s.tasks {
+		if _, err := crontask.Upsert(s.db, t.Name, t.Expression, t.Schedule.Next(now)); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.loop(ctx, s.done)
+	return nil
+}
+
+// Stop stops the checks, cancels the context of the running tasks, and waits
+// for them to finish.
+func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel = nil
+	s.mutex.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-done
+	s.wg.Wait()
+}
+
+// loop runs a check on every interval.
+func (s *Scheduler) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(time.Duration(s.info.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(ctx, time.Now()); err != nil {
+			log.Println("cron:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce starts the tasks that are due at the time now in the background.
+// A task is only started if this instance acquires its lock.
+func (s *Scheduler) RunOnce(ctx context.Context, now time.Time) error {
+	now = now.UTC()
+	lockedUntil := now.Add(time.Duration(s.info.LockTimeout) * time.Second)
+
+	for _, t := range s.Tasks() {
+		ok, err := crontask.Acquire(s.db, t.Name, s.owner, now, t.Schedule.Next(now), lockedUntil)
+		if err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+
+		s.wg.Add(1)
+		go s.run(ctx, t, lockedUntil)
+	}
+
+	return nil
+}
+
+// run runs a task, stores the result, and releases the lock.
+func (s *Scheduler) run(ctx context.Context, t Task, lockedUntil time.Time) {
+	defer s.wg.Done()
+
+	ctx, cancel := context.WithDeadline(ctx, lockedUntil)
+	defer cancel()
+
+	result, err := cronrun.Create(s.db, t.Name, s.owner, time.Now())
+	var ID int64
+	if err == nil {
+		ID, err = result.LastInsertId()
+	}
+	if err != nil {
+		log.Println("cron:", err)
+	}
+
+	runErr := call(ctx, t)
+	if runErr != nil {
+		log.Printf("cron: task %v failed: %v\n", t.Name, runErr)
+	}
+
+	if ID > 0 {
+		if _, err := cronrun.Finish(s.db, ID, runErr, time.Now()); err != nil {
+			log.Println("cron:", err)
+		}
+	}
+
+	if _, err := crontask.Release(s.db, t.Name, s.owner); err != nil {
+		log.Println("cron:", err)
+	}
+}
+
+// call runs the task and returns a panic as an error.
+func call(ctx context.Context, t Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return t.Run(ctx)
+}
@@ -0,0 +1,60 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/cron"
+)
+
+// TestNext ensures the next time matches the expression.
+func TestNext(t *testing.T) {
+	// A Thursday
+	now := time.Date(2016, 6, 30, 2, 5, 30, 0, time.UTC)
+
+	for _, tc := range []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2016, 6, 30, 2, 6, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2016, 6, 30, 2, 15, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2016, 6, 30, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2016, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2016, 6, 30, 3, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2016, 6, 30, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2016, 7, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2016, 7, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2016, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	} {
+		s, err := cron.Parse(tc.expr)
+		if err != nil {
+			t.Errorf("%v: %v", tc.expr, err)
+			continue
+		}
+		if received := s.Next(now); !received.Equal(tc.expected) {
+			t.Errorf("%v: got %v, want %v", tc.expr, received, tc.expected)
+		}
+	}
+}
+
+// TestParse ensures invalid expressions are rejected.
+func TestParse(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := cron.Parse(expr); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
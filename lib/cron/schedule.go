@@ -0,0 +1,167 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar are true when the day fields match every day. When
+	// both day fields are restricted a day matches if either field matches.
+	domStar, dowStar bool
+}
+
+// field is the range of values of a field in an expression.
+type field struct {
+	name     string
+	min, max int
+}
+
+var (
+	fields = []field{
+		{"minute", 0, 59},
+		{"hour", 0, 23},
+		{"day of month", 1, 31},
+		{"month", 1, 12},
+		{"day of week", 0, 7},
+	}
+
+	macros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// Parse reads a standard five field cron expression: minute, hour, day of
+// month, month, and day of week. Each field is a comma separated list of
+// values, ranges like 1-5, and steps like */15 or 0-30/10. Sunday is 0 or 7.
+// The macros @yearly, @monthly, @weekly, @daily, and @hourly are supported.
+func Parse(expr string) (Schedule, error) {
+	s := Schedule{}
+
+	expr = strings.TrimSpace(expr)
+	if m, ok := macros[expr]; ok {
+		expr = m
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return s, fmt.Errorf("cron: expected %v fields in %q", len(fields), expr)
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, f := range fields {
+		b, err := parseField(parts[i], f)
+		if err != nil {
+			return s, err
+		}
+		bits[i] = b
+	}
+
+	s.minute, s.hour, s.dom, s.month, s.dow = bits[0], bits[1], bits[2], bits[3], bits[4]
+	s.domStar = strings.HasPrefix(parts[2], "*")
+	s.dowStar = strings.HasPrefix(parts[4], "*")
+
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	return s, nil
+}
+
+// parseField returns the bits of the values matched by a field.
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(s, ",") {
+		lo, hi, step := f.min, f.max, 1
+
+		rng := item
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("cron: invalid step in %v %q", f.name, item)
+			}
+			step = n
+			rng = item[:i]
+		}
+
+		if rng != "*" {
+			var err error
+			if i := strings.Index(rng, "-"); i >= 0 {
+				lo, err = strconv.Atoi(rng[:i])
+				if err == nil {
+					hi, err = strconv.Atoi(rng[i+1:])
+				}
+			} else {
+				lo, err = strconv.Atoi(rng)
+				// A single value with a step runs to the end of the range
+				if step == 1 {
+					hi = lo
+				}
+			}
+			if err != nil {
+				return 0, fmt.Errorf("cron: invalid %v %q", f.name, item)
+			}
+		}
+
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("cron: %v %q is out of range %v-%v", f.name, item, f.min, f.max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first time after t that matches the schedule. The time is
+// in the location of t. A zero time is returned if there is no match within
+// five years, such as for February 30.
+func (s Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// dayMatches returns true if the day of t matches the day fields.
+func (s Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
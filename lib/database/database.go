@@ -0,0 +1,83 @@
+// Package database selects the database and builds the statements that differ
+// between MySQL and PostgreSQL. The models write their SQL with ? placeholders
+// and rebind it for the driver of the connection.
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/blue-jay/blueprint/lib/postgresql"
+)
+
+const (
+	// MySQL uses the MySQL settings.
+	MySQL = "MySQL"
+	// PostgreSQL uses the PostgreSQL settings.
+	PostgreSQL = "PostgreSQL"
+)
+
+// Info selects the database.
+type Info struct {
+	// Type is MySQL or PostgreSQL and chooses the section of the settings
+	// used to connect. MySQL is used when it is empty.
+	Type string `json:"Type"`
+}
+
+// Driver is a database connection that knows the name of its driver.
+type Driver interface {
+	DriverName() string
+}
+
+// Connection is an interface for making queries.
+type Connection interface {
+	Driver
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+}
+
+// IsPostgreSQL returns true if the connection uses the PostgreSQL driver.
+func IsPostgreSQL(db Driver) bool {
+	return db.DriverName() == postgresql.Driver
+}
+
+// Quote returns the name of a table or a column quoted for the driver so a
+// reserved word like user can be used.
+func Quote(db Driver, name string) string {
+	if IsPostgreSQL(db) {
+		return `"` + name + `"`
+	}
+	return "`" + name + "`"
+}
+
+// Insert runs the INSERT statement and returns a result with the ID of the
+// new row. The PostgreSQL driver does not return the ID so the statement
+// returns the id column instead.
+func Insert(db Connection, query string, args ...interface{}) (sql.Result, error) {
+	if !IsPostgreSQL(db) {
+		return db.Exec(db.Rebind(query), args...)
+	}
+
+	var ID int64
+	err := db.Get(&ID, db.Rebind(fmt.Sprintf("%v RETURNING id", query)), args...)
+	if err != nil {
+		return nil, err
+	}
+	return result{ID}, nil
+}
+
+// result is the result of an INSERT statement that returned the ID.
+type result struct {
+	ID int64
+}
+
+// LastInsertId returns the ID of the new row.
+func (r result) LastInsertId() (int64, error) {
+	return r.ID, nil
+}
+
+// RowsAffected returns the one row that was added.
+func (r result) RowsAffected() (int64, error) {
+	return 1, nil
+}
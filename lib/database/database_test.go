@@ -0,0 +1,95 @@
+// Package database_test
+package database_test
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/blue-jay/blueprint/lib/database"
+)
+
+// fakeDB records the statements instead of running them.
+type fakeDB struct {
+	driver string
+	exec   string
+	get    string
+}
+
+func (f *fakeDB) DriverName() string {
+	return f.driver
+}
+
+func (f *fakeDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	f.exec = query
+	return nil, nil
+}
+
+func (f *fakeDB) Get(dest interface{}, query string, args ...interface{}) error {
+	f.get = query
+	*dest.(*int64) = 42
+	return nil
+}
+
+func (f *fakeDB) Rebind(query string) string {
+	if f.driver != "postgres" {
+		return query
+	}
+	for i := 1; strings.Contains(query, "?"); i++ {
+		query = strings.Replace(query, "?", "$"+strconv.Itoa(i), 1)
+	}
+	return query
+}
+
+// TestInsertPostgreSQL ensures the ID is returned by the statement.
+func TestInsertPostgreSQL(t *testing.T) {
+	db := &fakeDB{driver: "postgres"}
+
+	result, err := database.Insert(db, "INSERT INTO note (name, user_id) VALUES (?,?)", "a", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "INSERT INTO note (name, user_id) VALUES ($1,$2) RETURNING id"
+	if db.get != want {
+		t.Errorf("got %q, want %q", db.get, want)
+	}
+	if db.exec != "" {
+		t.Errorf("got Exec %q, want none", db.exec)
+	}
+
+	ID, _ := result.LastInsertId()
+	if ID != 42 {
+		t.Errorf("got ID %v, want 42", ID)
+	}
+}
+
+// TestInsertMySQL ensures the statement is run unchanged.
+func TestInsertMySQL(t *testing.T) {
+	db := &fakeDB{driver: "mysql"}
+
+	query := "INSERT INTO note (name, user_id) VALUES (?,?)"
+	if _, err := database.Insert(db, query, "a", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if db.exec != query {
+		t.Errorf("got %q, want %q", db.exec, query)
+	}
+	if db.get != "" {
+		t.Errorf("got Get %q, want none", db.get)
+	}
+}
+
+// TestQuote ensures the names are quoted for the driver.
+func TestQuote(t *testing.T) {
+	for driver, want := range map[string]string{
+		"mysql":    "`user`",
+		"postgres": `"user"`,
+	} {
+		if got := database.Quote(&fakeDB{driver: driver}, "user"); got != want {
+			t.Errorf("%v: got %v, want %v", driver, got, want)
+		}
+	}
+}
@@ -3,6 +3,17 @@ package env
 
 import (
 	"encoding/json"
+	"os"
+
+	"github.com/blue-jay/blueprint/lib/accesslog"
+	"github.com/blue-jay/blueprint/lib/cron"
+	"github.com/blue-jay/blueprint/lib/database"
+	"github.com/blue-jay/blueprint/lib/graceful"
+	"github.com/blue-jay/blueprint/lib/hook"
+	"github.com/blue-jay/blueprint/lib/jobs"
+	"github.com/blue-jay/blueprint/lib/metrics"
+	"github.com/blue-jay/blueprint/lib/postgresql"
+	"github.com/blue-jay/blueprint/lib/reminder"
 
 	"github.com/blue-jay/core/asset"
 	"github.com/blue-jay/core/email"
@@ -21,15 +32,24 @@ import (
 
 // Info structures the application settings.
 type Info struct {
-	Asset      asset.Info    `json:"Asset"`
-	Email      email.Info    `json:"Email"`
-	Form       form.Info     `json:"Form"`
-	Generation generate.Info `json:"Generation"`
-	MySQL      mysql.Info    `json:"MySQL"`
-	Server     server.Info   `json:"Server"`
-	Session    session.Info  `json:"Session"`
-	Template   view.Template `json:"Template"`
-	View       view.Info     `json:"View"`
+	AccessLog  accesslog.Info  `json:"AccessLog"`
+	Asset      asset.Info      `json:"Asset"`
+	Cron       cron.Info       `json:"Cron"`
+	Database   database.Info   `json:"Database"`
+	Email      email.Info      `json:"Email"`
+	Form       form.Info       `json:"Form"`
+	Generation generate.Info   `json:"Generation"`
+	Jobs       jobs.Info       `json:"Jobs"`
+	Metrics    metrics.Info    `json:"Metrics"`
+	MySQL      mysql.Info      `json:"MySQL"`
+	PostgreSQL postgresql.Info `json:"PostgreSQL"`
+	Reminder   reminder.Info   `json:"Reminder"`
+	Server     server.Info     `json:"Server"`
+	Session    session.Info    `json:"Session"`
+	Shutdown   graceful.Info   `json:"Shutdown"`
+	Template   view.Template   `json:"Template"`
+	View       view.Info       `json:"View"`
+	Webhook    hook.Info       `json:"Webhook"`
 	path       string
 }
 
@@ -50,13 +70,19 @@ func New(path string) *Info {
 	}
 }
 
-// LoadConfig reads the configuration file.
+// LoadConfig reads the configuration file and then applies the environment
+// variables that start with Prefix.
 func LoadConfig(configFile string) (*Info, error) {
 	// Create a new configuration with the path to the file
 	config := New(configFile)
 
 	// Load the configuration file
-	err := jsonconfig.Load(configFile, config)
+	if err := jsonconfig.Load(configFile, config); err != nil {
+		return config, err
+	}
+
+	// Override the settings from the environment
+	err := Override(config, Prefix, os.Environ())
 
 	// Return the configuration
 	return config, err
@@ -1,9 +1,14 @@
 package env_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/blue-jay/blueprint/lib/database"
 	"github.com/blue-jay/blueprint/lib/env"
 )
 
@@ -65,3 +70,224 @@ func TestRead(t *testing.T) {
 		t.Errorf("\n got: %v\nwant: %v", received, expected)
 	}
 }
+
+// TestOverride ensures the environment variables change the settings.
+func TestOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	secret := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(secret, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := env.New("env.json")
+	err = env.Override(config, env.Prefix, []string{
+		"BLUEPRINT_MYSQL_HOSTNAME=db.internal",
+		"BLUEPRINT_MYSQL_PORT=3307",
+		"BLUEPRINT_MYSQL_PASSWORD_FILE=" + secret,
+		"BLUEPRINT_MYSQL_MIGRATION_TABLE=migration_test",
+		"BLUEPRINT_SESSION_OPTIONS_SECURE=true",
+		"BLUEPRINT_ACCESSLOG_EXCLUDE=/static/, /healthz",
+		`BLUEPRINT_ACCESSLOG_SAMPLE={"/api/": 0.5}`,
+		"OTHER_MYSQL_USERNAME=ignored",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.MySQL.Hostname != "db.internal" || config.MySQL.Port != 3307 {
+		t.Errorf("got %v:%v, want db.internal:3307", config.MySQL.Hostname, config.MySQL.Port)
+	}
+	if config.MySQL.Password != "s3cret" {
+		t.Errorf("got password %q, want the file contents", config.MySQL.Password)
+	}
+	if config.MySQL.Migration.Table != "migration_test" {
+		t.Errorf("got table %v, want migration_test", config.MySQL.Migration.Table)
+	}
+	if !config.Session.Options.Secure {
+		t.Error("Secure was not set")
+	}
+	if expected := []string{"/static/", "/healthz"}; !reflect.DeepEqual(config.AccessLog.Exclude, expected) {
+		t.Errorf("got %v, want %v", config.AccessLog.Exclude, expected)
+	}
+	if config.AccessLog.Sample["/api/"] != 0.5 {
+		t.Errorf("got %v, want the sample rate from JSON", config.AccessLog.Sample)
+	}
+	if len(config.MySQL.Username) > 0 {
+		t.Error("variable without the prefix was used")
+	}
+}
+
+// TestOverrideInvalid ensures every invalid variable is reported.
+func TestOverrideInvalid(t *testing.T) {
+	err := env.Override(env.New("env.json"), env.Prefix, []string{
+		"BLUEPRINT_MYSQL_PORT=abc",
+		"BLUEPRINT_JOBS_ENABLED=maybe",
+		"BLUEPRINT_MYSQL_PASSWORD=a",
+		"BLUEPRINT_MYSQL_PASSWORD_FILE=/missing",
+	})
+	if err == nil {
+		t.Fatal("Override should have failed")
+	}
+
+	for _, name := range []string{"BLUEPRINT_MYSQL_PORT", "BLUEPRINT_JOBS_ENABLED", "BLUEPRINT_MYSQL_PASSWORD_FILE"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not mention %v", err, name)
+		}
+	}
+}
+
+// TestValidateExample ensures the example settings are valid.
+func TestValidateExample(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	config, err := env.LoadConfig("env.json.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	// The PostgreSQL settings are valid too
+	config.Database.Type = database.PostgreSQL
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestValidate ensures every problem is reported at once.
+func TestValidate(t *testing.T) {
+	config := env.New("env.json")
+	config.Session.AuthKey = "not base64!"
+	config.Session.EncryptKey = "c2hvcnQ="
+	config.Server.UseHTTPS = true
+	config.Server.HTTPSPort = 70000
+	config.Server.CertFile = "missing.crt"
+	config.MySQL.Port = 3306
+	config.AccessLog.Format = "xml"
+	config.Metrics.AllowIPs = []string{"10.0.0.0/8", "localhost"}
+	config.Reminder.Enabled = true
+	config.Webhook.Enabled = true
+
+	err := config.Validate()
+	problems, ok := err.(env.Problems)
+	if !ok {
+		t.Fatalf("got %v, want Problems", err)
+	}
+
+	for _, s := range []string{
+		"Session.AuthKey is not valid base64",
+		"Session.EncryptKey must decode to 16, 24 or 32 bytes, not 5",
+		"Session.CSRFKey is required",
+		"Server.HTTPSPort must be between 1 and 65535",
+		`Server.CertFile "missing.crt" does not exist`,
+		"Server.KeyFile is required",
+		"MySQL.Username is required",
+		"MySQL.Parameter must contain parseTime=true",
+		"View.Folder is required",
+		`AccessLog.Format must be json or logfmt, not "xml"`,
+		`Metrics.AllowIPs "localhost"`,
+		"Reminder.Enabled needs Jobs.Enabled",
+		"Webhook.Enabled needs Jobs.Enabled",
+	} {
+		found := false
+		for _, p := range problems {
+			if strings.HasPrefix(p, s) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("problem %q was not reported in:\n%v", s, err)
+		}
+	}
+
+	if strings.Contains(err.Error(), "10.0.0.0/8") {
+		t.Error("valid CIDR range was reported")
+	}
+}
+
+// TestValidateDatabase ensures only the settings of the chosen database are
+// checked.
+func TestValidateDatabase(t *testing.T) {
+	config := env.New("env.json")
+	config.Database.Type = database.PostgreSQL
+	config.PostgreSQL.Port = 5432
+	config.PostgreSQL.Parameter = "sslmode=%zz"
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, s := range []string{
+		"PostgreSQL.Username is required",
+		"PostgreSQL.Parameter is not a valid query string",
+		"PostgreSQL.Migration.Table is required",
+	} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("problem %q was not reported in:\n%v", s, err)
+		}
+	}
+	if strings.Contains(err.Error(), "MySQL.") {
+		t.Errorf("MySQL settings were checked:\n%v", err)
+	}
+
+	config.Database.Type = "Oracle"
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), `Database.Type must be MySQL or PostgreSQL, not "Oracle"`) {
+		t.Errorf("unknown type was not reported in:\n%v", err)
+	}
+}
+
+// TestReload ensures only the reloadable changes are applied.
+func TestReload(t *testing.T) {
+	current := *env.New("env.json")
+	current.Server.HTTPPort = 80
+	current.View.Caching = true
+	current.View.BaseURI = "/"
+
+	next := current
+	next.Server.HTTPPort = 8080
+	next.View.Caching = false
+	next.Metrics.Enabled = true
+	next.Metrics.AllowIPs = []string{"10.0.0.0/8"}
+	next.AccessLog.Sample = map[string]float64{"/api/": 0.1}
+	next.Jobs.Enabled = true
+	next.Jobs.Workers = 4
+	next.Webhook.Enabled = true
+
+	updated, applied, restart := env.Reload(current, next)
+
+	expected := []string{"AccessLog.Sample", "Jobs.Enabled", "Metrics.Enabled", "Metrics.AllowIPs", "View.Caching", "Webhook.Enabled"}
+	if !reflect.DeepEqual(applied, expected) {
+		t.Errorf("applied %v, want %v", applied, expected)
+	}
+	if !reflect.DeepEqual(restart, []string{"Jobs.Workers", "Server.HTTPPort"}) {
+		t.Errorf("restart %v, want [Jobs.Workers Server.HTTPPort]", restart)
+	}
+
+	if updated.Server.HTTPPort != 80 {
+		t.Errorf("got port %v, want the current port", updated.Server.HTTPPort)
+	}
+	if updated.View.Caching || !updated.Metrics.Enabled || updated.AccessLog.Sample["/api/"] != 0.1 {
+		t.Errorf("reloadable settings were not applied: %+v", updated)
+	}
+	if updated.Path() != "env.json" {
+		t.Errorf("got path %v, want env.json", updated.Path())
+	}
+	if current.Metrics.Enabled {
+		t.Error("current settings were changed")
+	}
+}
@@ -0,0 +1,160 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Prefix is the start of the environment variables that change the settings.
+const Prefix = "BLUEPRINT"
+
+// fileSuffix is the end of an environment variable that holds the path of a
+// file with the value, like a secret mounted by a container platform.
+const fileSuffix = "_FILE"
+
+// Override changes the settings from the environment variables that start
+// with the prefix. The name of a variable is the prefix and the path of the
+// setting in upper case joined by underscores, like BLUEPRINT_MYSQL_PASSWORD
+// for MySQL.Password. A variable that ends in _FILE is read from the file at
+// the path it holds. Lists and maps can be set as JSON and a list of strings
+// can also be set as comma-separated values.
+func Override(v interface{}, prefix string, environ []string) error {
+	vars := make(map[string]string)
+	for _, kv := range environ {
+		if i := strings.Index(kv, "="); i > 0 && strings.HasPrefix(kv[:i], prefix+"_") {
+			vars[kv[:i]] = kv[i+1:]
+		}
+	}
+	if len(vars) == 0 {
+		return nil
+	}
+
+	used := make(map[string]bool)
+	var errs []string
+	override(reflect.ValueOf(v).Elem(), prefix, vars, used, &errs)
+
+	// A variable with a typo would be ignored without a warning
+	for name := range vars {
+		if !used[name] && !used[strings.TrimSuffix(name, fileSuffix)] {
+			log.Println("env: unknown setting", name)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("env: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// override sets the exported fields of the struct from the variables.
+func override(v reflect.Value, name string, vars map[string]string, used map[string]bool, errs *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		key := f.Name
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if len(tag) > 0 {
+			key = tag
+		}
+		key = name + "_" + strings.ToUpper(key)
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			override(field, key, vars, used, errs)
+			continue
+		}
+
+		value, ok, err := lookup(key, vars)
+		if err != nil {
+			used[key] = true
+			*errs = append(*errs, err.Error())
+			continue
+		}
+		if !ok {
+			continue
+		}
+		used[key] = true
+
+		if err := set(field, value); err != nil {
+			*errs = append(*errs, fmt.Sprintf("%v: %v", key, err))
+		}
+	}
+}
+
+// lookup returns the value of the variable or the contents of the file from
+// the variable with the _FILE suffix.
+func lookup(key string, vars map[string]string) (string, bool, error) {
+	value, ok := vars[key]
+	path, fromFile := vars[key+fileSuffix]
+	if ok && fromFile {
+		return "", false, fmt.Errorf("%v and %v are both set", key, key+fileSuffix)
+	}
+	if !fromFile {
+		return value, ok, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%v: %v", key+fileSuffix, err)
+	}
+
+	// Editors and secret tools often add a line break at the end
+	return strings.TrimRight(string(b), "\r\n"), true, nil
+}
+
+// set parses the value into the field.
+func set(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+			var list []string
+			for _, s := range strings.Split(value, ",") {
+				if s = strings.TrimSpace(s); len(s) > 0 {
+					list = append(list, s)
+				}
+			}
+			field.Set(reflect.ValueOf(list).Convert(field.Type()))
+			return nil
+		}
+		fallthrough
+	default:
+		return json.Unmarshal([]byte(value), field.Addr().Interface())
+	}
+	return nil
+}
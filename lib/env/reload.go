@@ -0,0 +1,93 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Reloadable lists the settings that are read while the application runs so
+// a change can be applied without a restart. A section applies every setting
+// in it. A change to any other setting needs a restart.
+var Reloadable = []string{
+	"AccessLog.Exclude",
+	"AccessLog.Sample",
+	"Cron.Enabled",
+	"Jobs.Enabled",
+	"Metrics",
+	"Reminder.Enabled",
+	"View.Caching",
+	"Webhook.Enabled",
+}
+
+// Changes returns the paths of the settings that are different, like
+// Server.HTTPPort.
+func Changes(a Info, b Info) []string {
+	var paths []string
+	changes(reflect.ValueOf(a), reflect.ValueOf(b), "", &paths)
+	return paths
+}
+
+// changes adds the paths of the exported fields that are different.
+func changes(a reflect.Value, b reflect.Value, name string, paths *[]string) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		path := f.Name
+		if len(name) > 0 {
+			path = name + "." + f.Name
+		}
+
+		fa, fb := a.Field(i), b.Field(i)
+		if fa.Kind() == reflect.Struct {
+			changes(fa, fb, path, paths)
+			continue
+		}
+
+		if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+// Reload returns the current settings with the reloadable changes from next
+// applied. It also returns the paths that were applied and the paths that
+// changed but need a restart.
+func Reload(current Info, next Info) (Info, []string, []string) {
+	updated := current
+	var applied, restart []string
+
+	for _, path := range Changes(current, next) {
+		if !reloadable(path) {
+			restart = append(restart, path)
+			continue
+		}
+
+		field(&updated, path).Set(field(&next, path))
+		applied = append(applied, path)
+	}
+
+	return updated, applied, restart
+}
+
+// reloadable returns true if the setting at the path is in Reloadable.
+func reloadable(path string) bool {
+	for _, r := range Reloadable {
+		if path == r || strings.HasPrefix(path, r+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// field returns the setting at the path.
+func field(c *Info, path string) reflect.Value {
+	v := reflect.ValueOf(c).Elem()
+	for _, name := range strings.Split(path, ".") {
+		v = v.FieldByName(name)
+	}
+	return v
+}
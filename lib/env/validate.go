@@ -0,0 +1,200 @@
+package env
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blue-jay/blueprint/lib/accesslog"
+	"github.com/blue-jay/blueprint/lib/database"
+)
+
+// Problems is the list of settings that are not valid.
+type Problems []string
+
+// Error returns every problem on a separate line.
+func (p Problems) Error() string {
+	return "env: the settings are not valid:\n  " + strings.Join(p, "\n  ")
+}
+
+// validator collects the problems with the settings.
+type validator struct {
+	problems Problems
+}
+
+// add records a problem.
+func (v *validator) add(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+// required records a problem when the value is empty.
+func (v *validator) required(name string, value string) {
+	if len(strings.TrimSpace(value)) == 0 {
+		v.add("%v is required", name)
+	}
+}
+
+// port records a problem when the port is out of range.
+func (v *validator) port(name string, port int) {
+	if port < 1 || port > 65535 {
+		v.add("%v must be between 1 and 65535, not %v", name, port)
+	}
+}
+
+// key records a problem when the value is not base64 or does not decode to
+// one of the lengths in bytes.
+func (v *validator) key(name string, value string, lengths ...int) {
+	if len(value) == 0 {
+		v.add("%v is required", name)
+		return
+	}
+
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		v.add("%v is not valid base64: %v", name, err)
+		return
+	}
+
+	for _, n := range lengths {
+		if len(b) == n {
+			return
+		}
+	}
+	v.add("%v must decode to %v bytes, not %v", name, join(lengths), len(b))
+}
+
+// exists records a problem when the path does not exist or is not the right
+// type.
+func (v *validator) exists(name string, path string, dir bool) {
+	if len(path) == 0 {
+		v.add("%v is required", name)
+		return
+	}
+
+	fi, err := os.Stat(path)
+	switch {
+	case err != nil:
+		v.add("%v %q does not exist", name, path)
+	case dir && !fi.IsDir():
+		v.add("%v %q is not a folder", name, path)
+	case !dir && fi.IsDir():
+		v.add("%v %q is not a file", name, path)
+	}
+}
+
+// join returns the numbers as a list like "16, 24, or 32".
+func join(list []int) string {
+	s := make([]string, len(list))
+	for i, n := range list {
+		s[i] = fmt.Sprint(n)
+	}
+	if len(s) < 2 {
+		return strings.Join(s, "")
+	}
+	return strings.Join(s[:len(s)-1], ", ") + " or " + s[len(s)-1]
+}
+
+// Validate checks the settings and returns every problem at once as
+// Problems. Paths are checked from the working directory.
+func (c *Info) Validate() error {
+	v := &validator{}
+
+	// Session
+	v.key("Session.AuthKey", c.Session.AuthKey, 32, 64)
+	v.key("Session.EncryptKey", c.Session.EncryptKey, 16, 24, 32)
+	v.key("Session.CSRFKey", c.Session.CSRFKey, 32)
+	v.required("Session.Name", c.Session.Name)
+
+	// Server
+	if !c.Server.UseHTTP && !c.Server.UseHTTPS {
+		v.add("Server.UseHTTP or Server.UseHTTPS must be true")
+	}
+	if c.Server.UseHTTP {
+		v.port("Server.HTTPPort", c.Server.HTTPPort)
+	}
+	if c.Server.UseHTTPS {
+		v.port("Server.HTTPSPort", c.Server.HTTPSPort)
+		v.exists("Server.CertFile", c.Server.CertFile, false)
+		v.exists("Server.KeyFile", c.Server.KeyFile, false)
+	}
+
+	// Database
+	switch c.Database.Type {
+	case "", database.MySQL:
+		v.required("MySQL.Username", c.MySQL.Username)
+		v.required("MySQL.Database", c.MySQL.Database)
+		v.required("MySQL.Hostname", c.MySQL.Hostname)
+		v.port("MySQL.Port", c.MySQL.Port)
+		if !strings.Contains(c.MySQL.Parameter, "parseTime=true") {
+			v.add("MySQL.Parameter must contain parseTime=true")
+		}
+		v.exists("MySQL.Migration.Folder", c.MySQL.Migration.Folder, true)
+		v.required("MySQL.Migration.Table", c.MySQL.Migration.Table)
+	case database.PostgreSQL:
+		v.required("PostgreSQL.Username", c.PostgreSQL.Username)
+		v.required("PostgreSQL.Database", c.PostgreSQL.Database)
+		v.required("PostgreSQL.Hostname", c.PostgreSQL.Hostname)
+		v.port("PostgreSQL.Port", c.PostgreSQL.Port)
+		if _, err := url.ParseQuery(c.PostgreSQL.Parameter); err != nil {
+			v.add("PostgreSQL.Parameter is not a valid query string: %v", err)
+		}
+		v.exists("PostgreSQL.Migration.Folder", c.PostgreSQL.Migration.Folder, true)
+		v.required("PostgreSQL.Migration.Table", c.PostgreSQL.Migration.Table)
+	default:
+		v.add("Database.Type must be %v or %v, not %q", database.MySQL, database.PostgreSQL, c.Database.Type)
+	}
+
+	// Views and assets
+	v.exists("Asset.Folder", c.Asset.Folder, true)
+	v.exists("View.Folder", c.View.Folder, true)
+	v.required("View.Extension", c.View.Extension)
+	v.required("Template.Root", c.Template.Root)
+	if len(c.View.Folder) > 0 && len(c.View.Extension) > 0 {
+		for _, name := range append([]string{c.Template.Root}, c.Template.Children...) {
+			if len(name) > 0 {
+				v.exists("Template", filepath.Join(c.View.Folder, name+"."+c.View.Extension), false)
+			}
+		}
+	}
+
+	// Email
+	if len(c.Email.Hostname) > 0 {
+		v.port("Email.Port", c.Email.Port)
+	}
+
+	// The reminders and webhooks are sent by the job queue
+	if c.Reminder.Enabled && !c.Jobs.Enabled {
+		v.add("Reminder.Enabled needs Jobs.Enabled to send the emails")
+	}
+	if c.Webhook.Enabled && !c.Jobs.Enabled {
+		v.add("Webhook.Enabled needs Jobs.Enabled to send the deliveries")
+	}
+
+	// Access log
+	switch c.AccessLog.Format {
+	case "", accesslog.JSON, accesslog.Logfmt:
+	default:
+		v.add("AccessLog.Format must be %v or %v, not %q", accesslog.JSON, accesslog.Logfmt, c.AccessLog.Format)
+	}
+	for prefix, rate := range c.AccessLog.Sample {
+		if rate < 0 || rate > 1 {
+			v.add("AccessLog.Sample for %q must be between 0 and 1, not %v", prefix, rate)
+		}
+	}
+
+	// Metrics
+	for _, a := range c.Metrics.AllowIPs {
+		if _, _, err := net.ParseCIDR(a); err != nil && net.ParseIP(a) == nil {
+			v.add("Metrics.AllowIPs %q is not an IP address or CIDR range", a)
+		}
+	}
+
+	if len(v.problems) > 0 {
+		return v.problems
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package flight
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/blue-jay/blueprint/lib/accesslog"
+	"github.com/blue-jay/blueprint/lib/collab"
+	"github.com/blue-jay/blueprint/lib/env"
+	"github.com/blue-jay/blueprint/lib/graceful"
+	"github.com/blue-jay/blueprint/lib/health"
+	"github.com/blue-jay/blueprint/lib/hook"
+	"github.com/blue-jay/blueprint/lib/metrics"
+	"github.com/blue-jay/blueprint/lib/noteevent"
+	"github.com/blue-jay/blueprint/lib/pubsub"
+	"github.com/blue-jay/blueprint/lib/route"
+
+	"github.com/blue-jay/core/xsrf"
+
+	"github.com/gorilla/sessions"
+	"github.com/jmoiron/sqlx"
+)
+
+// App holds the settings and the services of the application. It is built
+// once at startup and given to every request by Handler. The routes, the
+// event handlers, the health checks, the shutdown hooks, and the metrics
+// belong to the App so more than one can run in a process.
+type App struct {
+	DB        *sqlx.DB
+	Hub       *pubsub.Hub
+	Collab    *collab.Hub
+	AccessLog *accesslog.Logger
+	Webhooks  *hook.Dispatcher
+	Xsrf      xsrf.Info
+
+	Router  *route.Router
+	Events  *noteevent.Bus
+	Health  *health.Checks
+	Metrics *metrics.Metrics
+	Stop    *graceful.Hooks
+
+	config env.Info
+	mutex  sync.RWMutex
+}
+
+// NewApp returns an application with the settings and without any routes,
+// event handlers, health checks, or shutdown hooks.
+func NewApp(config env.Info) *App {
+	return &App{
+		Router:  route.New(),
+		Events:  noteevent.New(),
+		Health:  health.New(),
+		Metrics: metrics.New(),
+		Stop:    graceful.NewHooks(),
+		config:  config,
+	}
+}
+
+// Config returns the application settings.
+func (a *App) Config() env.Info {
+	a.mutex.RLock()
+	c := a.config
+	a.mutex.RUnlock()
+	return c
+}
+
+// StoreConfig replaces the application settings when they are reloaded. The
+// requests that already started keep the settings they read.
+func (a *App) StoreConfig(config env.Info) {
+	a.mutex.Lock()
+	a.config = config
+	a.mutex.Unlock()
+}
+
+// Handler stores the application in the request context so Context can read
+// it. It must run before any handler that calls Context.
+func (a *App) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := &state{app: a, config: a.Config()}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), key{}, s)))
+	})
+}
+
+// FromContext returns the application from the context or nil.
+func FromContext(ctx context.Context) *App {
+	if s, ok := ctx.Value(key{}).(*state); ok {
+		return s.app
+	}
+	return nil
+}
+
+// key is the type of the context key so it does not collide with other keys.
+type key struct{}
+
+// state is what is read once per request.
+type state struct {
+	app    *App
+	config env.Info
+
+	once sync.Once
+	sess *sessions.Session
+	err  error
+}
+
+// session returns the session of the request and loads it the first time.
+func (s *state) session(r *http.Request) (*sessions.Session, error) {
+	s.once.Do(func() {
+		s.sess, s.err = s.config.Session.Instance(r)
+	})
+	return s.sess, s.err
+}
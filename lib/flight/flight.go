@@ -1,13 +1,14 @@
-// Package flight provides access to the application settings safely.
+// Package flight provides access to the application settings and services
+// from the requests.
 package flight
 
 import (
 	"fmt"
 	"log"
 	"net/http"
-	"sync"
 
 	"github.com/blue-jay/blueprint/lib/env"
+	"github.com/blue-jay/blueprint/middleware/requestid"
 
 	"github.com/blue-jay/core/flash"
 	"github.com/blue-jay/core/form"
@@ -18,46 +19,31 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-var (
-	configInfo env.Info
-	dbInfo     *sqlx.DB
-
-	mutex sync.RWMutex
-)
-
-// StoreConfig stores the application settings so controller functions can
-//access them safely.
-func StoreConfig(ci env.Info) {
-	mutex.Lock()
-	configInfo = ci
-	mutex.Unlock()
-}
-
-// StoreDB stores the database connection settings so controller functions can
-// access them safely.
-func StoreDB(db *sqlx.DB) {
-	mutex.Lock()
-	dbInfo = db
-	mutex.Unlock()
-}
-
 // Info structures the application settings.
 type Info struct {
-	Config env.Info
-	Sess   *sessions.Session
-	UserID string
-	W      http.ResponseWriter
-	R      *http.Request
-	View   view.Info
-	DB     *sqlx.DB
+	Config    env.Info
+	Sess      *sessions.Session
+	UserID    string
+	RequestID string
+	W         http.ResponseWriter
+	R         *http.Request
+	View      view.Info
+	DB        *sqlx.DB
+	App       *App
 }
 
-// Context returns the application settings.
+// Context returns the application settings and the session of the request.
+// The session is only loaded the first time it is called for a request.
 func Context(w http.ResponseWriter, r *http.Request) Info {
 	var id string
 
+	s, ok := r.Context().Value(key{}).(*state)
+	if !ok {
+		panic("flight: the request did not pass through App.Handler")
+	}
+
 	// Get the session
-	sess, err := configInfo.Session.Instance(r)
+	sess, err := s.session(r)
 
 	// If the session is valid
 	if err == nil {
@@ -65,29 +51,21 @@ func Context(w http.ResponseWriter, r *http.Request) Info {
 		id = fmt.Sprintf("%v", sess.Values["id"])
 	}
 
-	mutex.RLock()
 	i := Info{
-		Config: configInfo,
-		Sess:   sess,
-		UserID: id,
-		W:      w,
-		R:      r,
-		View:   configInfo.View,
-		DB:     dbInfo,
+		Config:    s.config,
+		Sess:      sess,
+		UserID:    id,
+		RequestID: requestid.FromContext(r.Context()),
+		W:         w,
+		R:         r,
+		View:      s.config.View,
+		DB:        s.app.DB,
+		App:       s.app,
 	}
-	mutex.RUnlock()
 
 	return i
 }
 
-// Reset will delete all package globals
-func Reset() {
-	mutex.Lock()
-	configInfo = env.Info{}
-	dbInfo = &sqlx.DB{}
-	mutex.Unlock()
-}
-
 // Param gets the URL parameter.
 func (c *Info) Param(name string) string {
 	return router.Param(c.R, name)
@@ -133,16 +111,26 @@ func (c *Info) FlashWarning(message string) {
 	c.Sess.Save(c.R, c.W)
 }
 
-// FlashError saves an error flash and logs the error.
+// FlashError saves an error flash and logs the error with the request ID.
 func (c *Info) FlashError(err error) {
-	log.Println(err)
+	c.log(err)
 	c.Sess.AddFlash(flash.Info{err.Error(), flash.Error})
 	c.Sess.Save(c.R, c.W)
 }
 
-// FlashErrorGeneric saves a generic error flash and logs the error.
+// FlashErrorGeneric saves a generic error flash with the request ID so the
+// user can quote it and logs the error with the request ID.
 func (c *Info) FlashErrorGeneric(err error) {
-	log.Println(err)
-	c.Sess.AddFlash(flash.Info{"An error occurred on the server. Please try again later.", flash.Error})
+	c.log(err)
+	message := "An error occurred on the server. Please try again later."
+	if len(c.RequestID) > 0 {
+		message += " Request ID: " + c.RequestID
+	}
+	c.Sess.AddFlash(flash.Info{message, flash.Error})
 	c.Sess.Save(c.R, c.W)
 }
+
+// log writes the error to the log with the request ID.
+func (c *Info) log(err error) {
+	log.Output(3, fmt.Sprintf("request_id=%v %v", c.RequestID, err))
+}
@@ -2,48 +2,196 @@
 package flight_test
 
 import (
-	"log"
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/blue-jay/blueprint/lib/env"
 	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/noteevent"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
-// TestRace tests for race conditions.
-func TestRace(t *testing.T) {
-	for i := 0; i < 100; i++ {
-		go func() {
-			// Load the configuration file
-			config, err := env.LoadConfig("../../env.json.example")
-			if err != nil {
-				t.Fatal(err)
-			}
+// newApp returns an application with the example settings.
+func newApp(t *testing.T) *flight.App {
+	// Load the configuration file
+	config, err := env.LoadConfig("../../env.json.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Set up the session cookie store
+	config.Session.SetupConfig()
+
+	// Set up the views
+	config.View.SetTemplates(config.Template.Root, config.Template.Children)
+
+	return flight.NewApp(*config)
+}
+
+// serve calls the handler behind the application and returns the context
+// it received.
+func serve(t *testing.T, app *flight.App, fn func(c flight.Info)) {
+	r, err := http.NewRequest("GET", "http://localhost/foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-			// Set up the session cookie store
-			config.Session.SetupConfig()
+	app.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fn(flight.Context(w, r))
+	})).ServeHTTP(httptest.NewRecorder(), r)
+}
+
+// TestApps ensures instances in one process do not share settings.
+func TestApps(t *testing.T) {
+	for _, name := range []string{"one", "two"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
 
-			// Set up the views
-			config.View.SetTemplates(config.Template.Root, config.Template.Children)
+			app := newApp(t)
+			config := app.Config()
+			config.View.BaseURI = "/" + name + "/"
+			app.StoreConfig(config)
 
-			// Store the view in flight
-			flight.StoreConfig(*config)
+			for i := 0; i < 100; i++ {
+				serve(t, app, func(c flight.Info) {
+					if c.View.BaseURI != "/"+name+"/" {
+						t.Errorf("got BaseURI %v, want /%v/", c.View.BaseURI, name)
+					}
+					if c.App != app {
+						t.Error("got the context of another app")
+					}
 
-			// Test the context retrieval
-			w := httptest.NewRecorder()
-			r, err := http.NewRequest("GET", "http://localhost/foo", nil)
-			if err != nil {
-				t.Fatal(err)
+					// The copy can be changed by the handler
+					c.Config.Asset.Folder = "foo"
+					c.View.BaseURI = "bar"
+				})
 			}
+		})
+	}
+}
 
-			c := flight.Context(w, r)
+// TestDatabases ensures two instances with different databases in one
+// process do not share the routes, the event handlers, the health checks, or
+// the metrics. The connections are opened without connecting.
+func TestDatabases(t *testing.T) {
+	var apps []*flight.App
+	for _, name := range []string{"blueprint_one", "blueprint_two"} {
+		db, err := sqlx.Open("mysql", "root@tcp(127.0.0.1:3306)/"+name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
 
-			c.Config.Asset.Folder = "foo"
-			log.Println(c.Config.Asset.Folder)
+		app := newApp(t)
+		app.DB = db
 
-			c.View.BaseURI = "bar"
-			log.Println(c.View.BaseURI)
-		}()
+		// The stats use the same name in every registry
+		if err := app.Metrics.RegisterDB(db.DB, "blueprint"); err != nil {
+			t.Errorf("could not register the database of %v: %v", name, err)
+		}
+		apps = append(apps, app)
 	}
+	a, b := apps[0], apps[1]
+
+	var got *sqlx.DB
+	a.Router.Get("/database", func(w http.ResponseWriter, r *http.Request) {
+		got = flight.Context(w, r).DB
+	})
+
+	w := httptest.NewRecorder()
+	a.Handler(a.Router).ServeHTTP(w, httptest.NewRequest("GET", "/database", nil))
+	if w.Code != http.StatusOK || got != a.DB {
+		t.Errorf("got status %v and another database, want %v and the database of the app", w.Code, http.StatusOK)
+	}
+
+	got = nil
+	w = httptest.NewRecorder()
+	b.Handler(b.Router).ServeHTTP(w, httptest.NewRequest("GET", "/database", nil))
+	if w.Code != http.StatusNotFound || got != nil {
+		t.Errorf("got status %v from the route of another app, want %v", w.Code, http.StatusNotFound)
+	}
+
+	var events []string
+	a.Events.Handle(func(e noteevent.Event) {
+		events = append(events, e.NoteID)
+	})
+	b.Events.Publish(nil, noteevent.Created, "1", "2")
+	a.Events.Publish(nil, noteevent.Created, "1", "3")
+	if len(events) != 1 || events[0] != "3" {
+		t.Errorf("got events %v, want [3]", events)
+	}
+
+	a.Health.Register("only", func(ctx context.Context) error { return nil })
+	if _, ok := b.Health.Run(context.Background()).Checks["only"]; ok {
+		t.Error("got the check of another app")
+	}
+
+	a.Metrics.NotesCreated.Inc()
+	if got := testutil.ToFloat64(b.Metrics.NotesCreated); got != 0 {
+		t.Errorf("got %v notes created in another app, want 0", got)
+	}
+}
+
+// TestContextOnce ensures the session and the settings are read once per
+// request.
+func TestContextOnce(t *testing.T) {
+	app := newApp(t)
+
+	serve(t, app, func(first flight.Info) {
+		config := app.Config()
+		config.View.BaseURI = "/reloaded/"
+		app.StoreConfig(config)
+
+		second := flight.Context(first.W, first.R)
+		if second.Sess != first.Sess {
+			t.Error("the session was loaded again")
+		}
+		if second.View.BaseURI != first.View.BaseURI {
+			t.Errorf("got BaseURI %v during the request, want %v", second.View.BaseURI, first.View.BaseURI)
+		}
+	})
+
+	serve(t, app, func(c flight.Info) {
+		if c.View.BaseURI != "/reloaded/" {
+			t.Errorf("got BaseURI %v, want /reloaded/", c.View.BaseURI)
+		}
+	})
+}
+
+// TestRace tests for race conditions when the settings are reloaded.
+func TestRace(t *testing.T) {
+	app := newApp(t)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			app.StoreConfig(app.Config())
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		serve(t, app, func(c flight.Info) {
+			c.Config.Asset.Folder = "foo"
+		})
+	}
+	<-done
+}
+
+// TestContextWithoutApp ensures a missing App.Handler is reported.
+func TestContextWithoutApp(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+
+	r := httptest.NewRequest("GET", "/", nil)
+	flight.Context(httptest.NewRecorder(), r)
 }
@@ -0,0 +1,332 @@
+// Package graceful runs the HTTP and HTTPS listeners and stops them without
+// dropping requests. SIGTERM and SIGINT stop accepting connections, wait for
+// the requests in flight, and then run the shutdown functions. When handoff
+// is enabled, SIGHUP starts a new copy of the binary that takes over the
+// listening sockets so the binary can be upgraded without downtime.
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/blue-jay/core/server"
+)
+
+// envListeners is the environment variable that tells a new process the
+// names of the sockets it was given. The sockets start at file descriptor 3.
+const envListeners = "GRACEFUL_LISTENERS"
+
+// Info holds the details for stopping the application.
+type Info struct {
+	// Timeout is the number of seconds to wait for the requests in flight
+	// before the connections are closed. The default is 30.
+	Timeout int `json:"Timeout"`
+	// Handoff starts a new process on SIGHUP that takes over the sockets. The
+	// new process sends SIGTERM to this process once it is serving. A process
+	// manager must allow the main process to change to use this. SIGHUP
+	// reloads the configuration file instead when it is off.
+	Handoff bool `json:"Handoff"`
+}
+
+// Hooks holds the functions that stop one application. Each application has
+// its own so more than one can run in a process.
+type Hooks struct {
+	drains    []func()
+	shutdowns []func()
+	mutex     sync.Mutex
+}
+
+// NewHooks returns hooks without any functions.
+func NewHooks() *Hooks {
+	return &Hooks{}
+}
+
+// OnDrain adds a function that runs as soon as the listeners stop. Use it to
+// end long-lived connections like event streams and WebSockets that would
+// otherwise hold the shutdown until the timeout.
+func (h *Hooks) OnDrain(fn func()) {
+	h.mutex.Lock()
+	h.drains = append(h.drains, fn)
+	h.mutex.Unlock()
+}
+
+// OnShutdown adds a function that runs after the requests in flight are
+// finished. The functions run in the reverse order they were added, like
+// defer, so a resource added first is closed last.
+func (h *Hooks) OnShutdown(fn func()) {
+	h.mutex.Lock()
+	h.shutdowns = append(h.shutdowns, fn)
+	h.mutex.Unlock()
+}
+
+// listener is a socket and the server that accepts its connections.
+type listener struct {
+	name string
+	net.Listener
+	srv *http.Server
+	tls bool
+}
+
+// Run starts the listeners from the server settings and blocks until the
+// application is stopped by a signal, then runs the functions in the hooks.
+// The settings work the same as server.Run.
+func Run(httpHandler http.Handler, httpsHandler http.Handler, s server.Info, info Info, hooks *Hooks) error {
+	if s.UseHTTP && s.UseHTTPS && s.RedirectToHTTPS {
+		httpHandler = redirect(s.HTTPSPort)
+	}
+
+	var list []*listener
+	if s.UseHTTP {
+		list = append(list, &listener{
+			name: "http",
+			srv:  &http.Server{Addr: address(s.Hostname, s.HTTPPort), Handler: httpHandler},
+		})
+	}
+	if s.UseHTTPS {
+		list = append(list, &listener{
+			name: "https",
+			srv:  &http.Server{Addr: address(s.Hostname, s.HTTPSPort), Handler: httpsHandler},
+			tls:  true,
+		})
+	}
+	if len(list) == 0 {
+		return errors.New("graceful: the config does not specify a listener to start")
+	}
+
+	inherited, err := inheritedFiles()
+	if err != nil {
+		return err
+	}
+	handedOff := len(inherited) > 0
+
+	for _, l := range list {
+		if f, ok := inherited[l.name]; ok {
+			l.Listener, err = net.FileListener(f)
+			delete(inherited, l.name)
+			f.Close()
+		} else {
+			l.Listener, err = net.Listen("tcp", l.srv.Addr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Close the sockets that are no longer in the settings
+	for _, f := range inherited {
+		f.Close()
+	}
+
+	// Stop the servers on a signal or when one of them fails
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	if info.Handoff {
+		signal.Notify(signals, syscall.SIGHUP)
+	}
+	defer signal.Stop(signals)
+
+	errs := make(chan error, len(list))
+	for _, l := range list {
+		go serve(l, s, errs)
+	}
+
+	// Tell the old process to stop now that the sockets are served
+	if handedOff {
+		if p, err := os.FindProcess(os.Getppid()); err == nil {
+			if err := p.Signal(syscall.SIGTERM); err != nil {
+				log.Println("graceful:", err)
+			}
+		}
+	}
+
+	err = wait(list, signals, errs)
+	shutdown(list, timeout(info), hooks)
+	return err
+}
+
+// wait blocks until a stop signal or a server error. After a SIGHUP the
+// sockets are handed off and this process keeps serving until the new process
+// sends SIGTERM.
+func wait(list []*listener, signals <-chan os.Signal, errs <-chan error) error {
+	for {
+		select {
+		case sig := <-signals:
+			if sig != syscall.SIGHUP {
+				log.Println("Stopping on", sig)
+				return nil
+			}
+			if err := handoff(list); err != nil {
+				log.Println("graceful: handoff failed:", err)
+			}
+		case err := <-errs:
+			return err
+		}
+	}
+}
+
+// serve accepts connections until the server is shut down.
+func serve(l *listener, s server.Info, errs chan<- error) {
+	log.Printf("Running %v %v", strings.ToUpper(l.name), l.srv.Addr)
+
+	var err error
+	if l.tls {
+		err = l.srv.ServeTLS(l.Listener, s.CertFile, s.KeyFile)
+	} else {
+		err = l.srv.Serve(l.Listener)
+	}
+	if err != nil && err != http.ErrServerClosed {
+		errs <- err
+	}
+}
+
+// shutdown stops the listeners, waits for the requests in flight up to the
+// timeout, and then runs the shutdown functions.
+func shutdown(list []*listener, d time.Duration, hooks *Hooks) {
+	hooks.mutex.Lock()
+	drains := append([]func(){}, hooks.drains...)
+	shutdowns := append([]func(){}, hooks.shutdowns...)
+	hooks.mutex.Unlock()
+
+	// The drain functions run once the first listener is closed
+	var once sync.Once
+	drained := make(chan struct{})
+	for _, l := range list {
+		l.srv.RegisterOnShutdown(func() {
+			once.Do(func() {
+				for _, fn := range drains {
+					fn()
+				}
+				close(drained)
+			})
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, l := range list {
+		wg.Add(1)
+		go func(l *listener) {
+			defer wg.Done()
+			if err := l.srv.Shutdown(ctx); err != nil {
+				log.Printf("graceful: %v requests did not finish in %v: %v", l.name, d, err)
+				l.srv.Close()
+			}
+		}(l)
+	}
+	wg.Wait()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		log.Println("graceful: drain did not finish in", d)
+	}
+
+	for i := len(shutdowns) - 1; i >= 0; i-- {
+		shutdowns[i]()
+	}
+}
+
+// handoff starts a new process with the same arguments that is given the
+// listening sockets.
+func handoff(list []*listener) error {
+	var files []*os.File
+	var names []string
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for _, l := range list {
+		fl, ok := l.Listener.(interface{ File() (*os.File, error) })
+		if !ok {
+			return fmt.Errorf("%v socket cannot be shared", l.name)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		names = append(names, l.name)
+	}
+
+	cmd := exec.Command(os.Args[0], os.Args[1:]...)
+	cmd.Env = append(os.Environ(), envListeners+"="+strings.Join(names, ","))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	log.Println("Started process", cmd.Process.Pid, "to take over the sockets")
+
+	// Log when the new process stops before it takes over
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			log.Println("graceful: new process stopped:", err)
+		}
+	}()
+
+	return nil
+}
+
+// inheritedFiles returns the sockets from the old process by name.
+func inheritedFiles() (map[string]*os.File, error) {
+	value := os.Getenv(envListeners)
+	if len(value) == 0 {
+		return nil, nil
+	}
+	os.Unsetenv(envListeners)
+
+	files := make(map[string]*os.File)
+	for i, name := range strings.Split(value, ",") {
+		f := os.NewFile(uintptr(3+i), name)
+		if f == nil {
+			return nil, fmt.Errorf("graceful: %v socket was not passed", name)
+		}
+		files[name] = f
+	}
+
+	return files, nil
+}
+
+// timeout returns how long to wait for the requests in flight.
+func timeout(info Info) time.Duration {
+	if info.Timeout <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(info.Timeout) * time.Second
+}
+
+// address returns the address to listen on.
+func address(hostname string, port int) string {
+	return fmt.Sprintf("%v:%v", hostname, port)
+}
+
+// redirect returns a handler that sends every request to HTTPS.
+func redirect(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if port != 443 {
+			host = net.JoinHostPort(host, fmt.Sprint(port))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
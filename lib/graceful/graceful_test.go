@@ -0,0 +1,109 @@
+package graceful_test
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/graceful"
+
+	"github.com/blue-jay/core/server"
+)
+
+// TestRun ensures a request in flight finishes after SIGTERM and the shutdown
+// functions run in reverse order after the drain functions.
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals are not supported")
+	}
+
+	// Find a free port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	var order []string
+	hooks := graceful.NewHooks()
+	hooks.OnDrain(func() { order = append(order, "drain") })
+	hooks.OnShutdown(func() { order = append(order, "db") })
+	hooks.OnShutdown(func() { order = append(order, "worker") })
+
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- graceful.Run(handler, handler, server.Info{
+			Hostname: "127.0.0.1",
+			UseHTTP:  true,
+			HTTPPort: port,
+		}, graceful.Info{Timeout: 5}, hooks)
+	}()
+
+	// Retry until the listener is ready
+	body := make(chan string, 1)
+	go func() {
+		var err error
+		for i := 0; i < 50; i++ {
+			var resp *http.Response
+			resp, err = http.Get("http://" + l.Addr().String())
+			if err == nil {
+				b, _ := ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+				body <- string(b)
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		body <- err.Error()
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("request did not start")
+	}
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := <-body; got != "done" {
+		t.Errorf("got %v, want done", got)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+	}
+
+	expected := []string{"drain", "worker", "db"}
+	if len(order) != len(expected) {
+		t.Fatalf("got %v, want %v", order, expected)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("got %v, want %v", order, expected)
+		}
+	}
+}
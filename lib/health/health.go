@@ -0,0 +1,250 @@
+// Package health runs the checks that tell a load balancer if the application
+// is ready to serve requests. Subsystems register their own checks and every
+// check runs at the same time with a timeout.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blue-jay/core/storage/migration"
+)
+
+const (
+	// OK is the status of a check that passed.
+	OK = "ok"
+	// Fail is the status of a check that failed.
+	Fail = "fail"
+)
+
+// Check returns an error when the subsystem is not ready.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a check.
+type Result struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Report is the outcome of every check. The status is OK only when every
+// check passed.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Checks holds the checks of one application. Each application has its own
+// so more than one can run in a process.
+type Checks struct {
+	// Timeout is how long a check can run before it fails.
+	Timeout time.Duration
+
+	checks map[string]Check
+	mutex  sync.RWMutex
+}
+
+// New returns the checks of an application without any checks.
+func New() *Checks {
+	return &Checks{
+		Timeout: 5 * time.Second,
+		checks:  map[string]Check{},
+	}
+}
+
+// Register adds a check with the name. A check with the same name is
+// replaced.
+func (c *Checks) Register(name string, fn Check) {
+	c.mutex.Lock()
+	c.checks[name] = fn
+	c.mutex.Unlock()
+}
+
+// Run runs every check at the same time and waits for them to finish.
+func (c *Checks) Run(ctx context.Context) Report {
+	c.mutex.RLock()
+	list := make(map[string]Check, len(c.checks))
+	for name, fn := range c.checks {
+		list[name] = fn
+	}
+	c.mutex.RUnlock()
+
+	report := Report{
+		Status: OK,
+		Checks: make(map[string]Result, len(list)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, fn := range list {
+		wg.Add(1)
+		go func(name string, fn Check) {
+			defer wg.Done()
+			result := run(ctx, c.Timeout, fn)
+
+			mu.Lock()
+			report.Checks[name] = result
+			if result.Status != OK {
+				report.Status = Fail
+			}
+			mu.Unlock()
+		}(name, fn)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// run runs a single check with the timeout. A check that does not return in
+// time is left to finish on its own.
+func run(ctx context.Context, timeout time.Duration, fn Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Status:     OK,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = Fail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// *****************************************************************************
+// Checks
+// *****************************************************************************
+
+// Pinger is a database connection that can be pinged.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Ping returns a check that fails when the database does not respond.
+func Ping(db Pinger) Check {
+	return func(ctx context.Context) error {
+		if db == nil {
+			return errors.New("database is not connected")
+		}
+		return db.PingContext(ctx)
+	}
+}
+
+// Selecter is a database connection that can read rows.
+type Selecter interface {
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// Migrations returns a check that fails when a migration in the folder has
+// not been applied to the database.
+func Migrations(db Selecter, info migration.Info) Check {
+	return func(ctx context.Context) error {
+		var applied []string
+		err := db.SelectContext(ctx, &applied, fmt.Sprintf("SELECT name FROM %v", info.Table))
+		if err != nil {
+			return err
+		}
+
+		pending, err := Pending(info.Folder, info.Extension, applied)
+		if err != nil {
+			return err
+		}
+
+		if len(pending) > 0 {
+			return fmt.Errorf("%v migration(s) not applied: %v", len(pending), strings.Join(pending, ", "))
+		}
+		return nil
+	}
+}
+
+// Pending returns the names of the up migrations in the folder that are not
+// in the applied list. The names are the file names without the .up and
+// file extension.
+func Pending(folder string, extension string, applied []string) ([]string, error) {
+	files, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := ".up." + extension
+	done := make(map[string]bool, len(applied))
+	for _, name := range applied {
+		done[strings.TrimSuffix(name, suffix)] = true
+	}
+
+	var pending []string
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), suffix) {
+			continue
+		}
+		name := strings.TrimSuffix(f.Name(), suffix)
+		if !done[name] {
+			pending = append(pending, name)
+		}
+	}
+
+	return pending, nil
+}
+
+// Templates returns a check that fails when a template in the folder with
+// the extension does not parse with the functions.
+func Templates(folder string, extension string, funcMaps ...template.FuncMap) Check {
+	funcs := template.FuncMap{}
+	for _, fm := range funcMaps {
+		for name, fn := range fm {
+			funcs[name] = fn
+		}
+	}
+
+	return func(ctx context.Context) error {
+		var failed []string
+		err := filepath.Walk(folder, func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if fi.IsDir() || filepath.Ext(path) != "."+extension {
+				return ctx.Err()
+			}
+
+			if _, err := template.New(fi.Name()).Funcs(funcs).ParseFiles(path); err != nil {
+				failed = append(failed, err.Error())
+			}
+			return ctx.Err()
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(failed) > 0 {
+			return errors.New(strings.Join(failed, "; "))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/health"
+)
+
+// TestRun ensures a failed, slow, or panicking check fails the report.
+func TestRun(t *testing.T) {
+	checks := health.New()
+	checks.Timeout = 50 * time.Millisecond
+
+	checks.Register("ok", func(ctx context.Context) error { return nil })
+	report := checks.Run(context.Background())
+	if report.Status != health.OK || report.Checks["ok"].Status != health.OK {
+		t.Fatalf("got %+v, want ok", report)
+	}
+
+	checks.Register("error", func(ctx context.Context) error { return errors.New("down") })
+	checks.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	checks.Register("panic", func(ctx context.Context) error { panic("boom") })
+
+	report = checks.Run(context.Background())
+	if report.Status != health.Fail {
+		t.Errorf("got status %v, want %v", report.Status, health.Fail)
+	}
+	if report.Checks["ok"].Status != health.OK {
+		t.Errorf("ok check got %+v", report.Checks["ok"])
+	}
+	for name, msg := range map[string]string{
+		"error": "down",
+		"slow":  context.DeadlineExceeded.Error(),
+		"panic": "panic: boom",
+	} {
+		if r := report.Checks[name]; r.Status != health.Fail || r.Error != msg {
+			t.Errorf("%v check got %+v, want error %v", name, r, msg)
+		}
+	}
+}
+
+// TestPending ensures only the up migrations that were not applied are
+// returned.
+func TestPending(t *testing.T) {
+	folder, err := ioutil.TempDir("", "health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+
+	for _, name := range []string{
+		"20160630_020000.000000_init.up.sql",
+		"20160630_020000.000000_init.down.sql",
+		"20261019_000100.000000_notebook.up.sql",
+		"20261019_000200.000000_tag.up.sql",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(folder, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pending, err := health.Pending(folder, "sql", []string{
+		"20160630_020000.000000_init",
+		"20261019_000100.000000_notebook.up.sql",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"20261019_000200.000000_tag"}
+	if !reflect.DeepEqual(pending, expected) {
+		t.Errorf("got %v, want %v", pending, expected)
+	}
+}
+
+// TestTemplates ensures a template with an unknown function fails.
+func TestTemplates(t *testing.T) {
+	folder, err := ioutil.TempDir("", "health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+
+	page := filepath.Join(folder, "page.tmpl")
+	if err := ioutil.WriteFile(page, []byte(`{{define "content"}}{{LINK "a" "b"}}{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	check := health.Templates(folder, "tmpl")
+	if err := check(context.Background()); err == nil {
+		t.Error("missing function was not reported")
+	}
+
+	check = health.Templates(folder, "tmpl", template.FuncMap{
+		"LINK": func(a, b string) string { return "" },
+	})
+	if err := check(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
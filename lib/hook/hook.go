@@ -0,0 +1,523 @@
+// Package hook sends the events of the application to the webhooks that are
+// registered for them. A job stores each event as a delivery in the database
+// for every webhook that receives it and another job sends each delivery as a
+// JSON payload that is signed with the secret of the webhook. Deliveries that
+// fail are sent again after a delay that doubles with each attempt. Deliveries
+// are not sent to loopback, private, or link-local addresses so a webhook
+// cannot reach the internal network.
+package hook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/jobs"
+	"github.com/blue-jay/blueprint/lib/noteevent"
+	"github.com/blue-jay/blueprint/model/note"
+	"github.com/blue-jay/blueprint/model/webhook"
+	"github.com/blue-jay/blueprint/model/webhookdelivery"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// UserRegistered is the type of event when a user creates an account.
+	UserRegistered = "user.registered"
+	// Ping is the type of event sent to test a webhook.
+	Ping = "ping"
+
+	// Dispatch is the type of job that stores the deliveries of a note event.
+	Dispatch = "webhook.dispatch"
+	// Deliver is the type of job that sends a delivery.
+	Deliver = "webhook.deliver"
+
+	// EventHeader is the header with the type of event.
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader is the header with the ID of the delivery. The ID is the
+	// same for every attempt.
+	DeliveryHeader = "X-Webhook-Delivery"
+	// TimestampHeader is the header with the time the request was sent in
+	// seconds since the Unix epoch.
+	TimestampHeader = "X-Webhook-Timestamp"
+	// SignatureHeader is the header with the signature of the timestamp and
+	// the body.
+	SignatureHeader = "X-Webhook-Signature"
+
+	// Tolerance is how far the timestamp of a request can be from the time it
+	// is verified. Older requests are rejected as replays.
+	Tolerance = 5 * time.Minute
+)
+
+var (
+	// Events is the list of events a webhook can receive.
+	Events = []string{
+		noteevent.Created,
+		noteevent.Updated,
+		noteevent.Deleted,
+		UserRegistered,
+	}
+
+	// ErrDisabled is returned when a delivery is queued while webhooks are
+	// disabled.
+	ErrDisabled = errors.New("webhooks are disabled")
+
+	// ErrAddress is returned when a webhook resolves to an address that is
+	// not allowed.
+	ErrAddress = errors.New("address is not allowed for a webhook")
+
+	// blocked are the ranges that are not public and are not covered by the
+	// methods of net.IP.
+	blocked = parseCIDRs(
+		"0.0.0.0/8",
+		"100.64.0.0/10",
+		"192.0.0.0/24",
+		"198.18.0.0/15",
+		"240.0.0.0/4",
+	)
+)
+
+// Info holds the details for the webhook dispatcher. The deliveries are sent
+// by the job queue so it must be enabled as well.
+type Info struct {
+	// Enabled queues a delivery for each event.
+	Enabled bool `json:"Enabled"`
+	// MaxAttempts is the number of attempts before a delivery fails.
+	MaxAttempts int `json:"MaxAttempts"`
+	// Backoff is the number of seconds before the second attempt. The delay
+	// doubles after each attempt.
+	Backoff int `json:"Backoff"`
+	// Timeout is the number of seconds to wait for a response.
+	Timeout int `json:"Timeout"`
+	// AllowLoopback allows webhooks on this computer for local testing.
+	AllowLoopback bool `json:"AllowLoopback"`
+}
+
+// Connection is an interface for making queries.
+type Connection interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
+}
+
+// TxConnection is an interface for making queries that can also start a
+// transaction.
+type TxConnection interface {
+	Connection
+	Beginx() (*sqlx.Tx, error)
+}
+
+// Event is the payload of a Dispatch job.
+type Event struct {
+	Type      string    `json:"type"`
+	UserID    string    `json:"user_id"`
+	NoteID    string    `json:"note_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Delivery is the payload of a Deliver job.
+type Delivery struct {
+	ID uint32 `json:"delivery_id"`
+}
+
+// Payload is the body sent to a webhook.
+type Payload struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Note is the data of a note event.
+type Note struct {
+	ID       string `json:"id"`
+	UserID   string `json:"user_id"`
+	Name     string `json:"name,omitempty"`
+	Notebook string `json:"notebook,omitempty"`
+	Version  uint32 `json:"version,omitempty"`
+}
+
+// Sign returns the signature of the request: the hex encoded HMAC-SHA256 of
+// the timestamp, a period, and the body with the secret as the key, prefixed
+// with "sha256=".
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true if the signature matches the timestamp and the body and
+// the timestamp is within the tolerance of the time now. Receivers can use it
+// to check a request came from the application and is not a replay.
+func Verify(secret string, timestamp string, body []byte, signature string, now time.Time) bool {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	if d := now.Sub(time.Unix(sec, 0)); d > Tolerance || d < -Tolerance {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// NewSecret returns a random secret for a webhook.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NewClient returns a client that only connects to public addresses. The
+// address is checked after it is resolved so a host name cannot point to the
+// internal network. Loopback addresses are allowed when allowLoopback is true.
+func NewClient(timeout time.Duration, allowLoopback bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !allowed(net.ParseIP(host), allowLoopback) {
+				return ErrAddress
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// allowed returns true if a webhook can connect to the address.
+func allowed(ip net.IP, allowLoopback bool) bool {
+	switch {
+	case ip == nil:
+		return false
+	case ip.IsLoopback():
+		return allowLoopback
+	case ip.IsPrivate(), ip.IsUnspecified(), ip.IsMulticast(),
+		ip.IsLinkLocalUnicast(), ip.IsLinkLocalMulticast():
+		return false
+	}
+
+	for _, n := range blocked {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCIDRs returns the networks in CIDR notation.
+func parseCIDRs(s ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(s))
+	for i, cidr := range s {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// Send posts the payload of a delivery to the URL at the time now and returns
+// the status code of the response. A response without a 2xx status code is an
+// error. The request is canceled with the context.
+func Send(ctx context.Context, client *http.Client, url string, secret string, event string, deliveryID uint32, payload []byte, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Blueprint-Webhook")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(deliveryID), 10))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Read some of the body so the connection can be reused
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with %v", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Dispatcher queues the deliveries of the events and sends them with the job
+// queue.
+type Dispatcher struct {
+	db      TxConnection
+	client  *http.Client
+	info    Info
+	enabled bool
+	mutex   sync.RWMutex
+}
+
+// New returns a dispatcher that does not queue deliveries until it is started.
+func New(db TxConnection, info Info) *Dispatcher {
+	if info.MaxAttempts <= 0 {
+		info.MaxAttempts = 8
+	}
+	if info.Backoff <= 0 {
+		info.Backoff = 30
+	}
+	if info.Timeout <= 0 {
+		info.Timeout = 10
+	}
+
+	return &Dispatcher{
+		db:     db,
+		client: NewClient(time.Duration(info.Timeout)*time.Second, info.AllowLoopback),
+		info:   info,
+	}
+}
+
+// Register sets the handlers of the Dispatch and Deliver jobs. The deliveries
+// that are already stored are sent even when the dispatcher is stopped.
+func (d *Dispatcher) Register(q *jobs.Queue) {
+	q.Register(Dispatch, d.dispatch)
+	q.Register(Deliver, d.deliver)
+}
+
+// Start queues a delivery for each event.
+func (d *Dispatcher) Start() {
+	d.mutex.Lock()
+	d.enabled = true
+	d.mutex.Unlock()
+}
+
+// Stop stops queuing deliveries for the events.
+func (d *Dispatcher) Stop() {
+	d.mutex.Lock()
+	d.enabled = false
+	d.mutex.Unlock()
+}
+
+// Enabled returns true if deliveries are queued.
+func (d *Dispatcher) Enabled() bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.enabled
+}
+
+// Enqueue stores a delivery of the event for each webhook of the user and each
+// global webhook that receives the event. Returns the number of deliveries.
+// Nothing is stored when the dispatcher is stopped.
+func (d *Dispatcher) Enqueue(event string, userID string, data interface{}, now time.Time) (int, error) {
+	if !d.Enabled() {
+		return 0, nil
+	}
+
+	items, err := webhook.Subscribed(d.db, event, userID)
+	if err != nil || len(items) == 0 {
+		return 0, err
+	}
+
+	// Store every delivery or none so a retry does not send one twice
+	err = note.Transaction(d.db, func(tx note.Connection) error {
+		for _, item := range items {
+			if err := enqueue(tx, item.ID, event, data, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(items), nil
+}
+
+// EnqueuePing stores a delivery of a ping event for a webhook.
+func (d *Dispatcher) EnqueuePing(webhookID uint32, now time.Time) error {
+	if !d.Enabled() {
+		return ErrDisabled
+	}
+	return note.Transaction(d.db, func(tx note.Connection) error {
+		return enqueue(tx, webhookID, Ping, map[string]uint32{"webhook_id": webhookID}, now)
+	})
+}
+
+// Redeliver sends a delivery of a webhook again. Returns false if the
+// delivery is already waiting to be sent.
+func (d *Dispatcher) Redeliver(ID string, webhookID uint32, now time.Time) (bool, error) {
+	if !d.Enabled() {
+		return false, ErrDisabled
+	}
+
+	deliveryID, err := strconv.ParseUint(ID, 10, 32)
+	if err != nil {
+		return false, err
+	}
+
+	queued := false
+	err = note.Transaction(d.db, func(tx note.Connection) error {
+		result, err := webhookdelivery.Retry(tx, ID, webhookID, now)
+		if err != nil {
+			return err
+		}
+
+		if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+			return err
+		}
+
+		queued = true
+		return jobs.EnqueueAt(tx, Deliver, Delivery{ID: uint32(deliveryID)}, now)
+	})
+	return queued, err
+}
+
+// enqueue stores a delivery of the event for a webhook and the job that sends
+// it in the transaction.
+func enqueue(tx note.Connection, webhookID uint32, event string, data interface{}, now time.Time) error {
+	b, err := json.Marshal(Payload{
+		Event:     event,
+		CreatedAt: now.UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	result, err := webhookdelivery.Create(tx, webhookID, event, string(b), now)
+	if err != nil {
+		return err
+	}
+
+	ID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	return jobs.EnqueueAt(tx, Deliver, Delivery{ID: uint32(ID)}, now)
+}
+
+// NoteHandler returns a function that queues a Dispatch job for a note event.
+// It is registered with the Handle method of noteevent.Bus. The webhooks and the note are read
+// by the job so the request that changed the note only stores the job. Errors
+// are logged because the change was already stored.
+func (d *Dispatcher) NoteHandler() func(noteevent.Event) {
+	return func(e noteevent.Event) {
+		if !d.Enabled() {
+			return
+		}
+
+		err := jobs.Enqueue(d.db, Dispatch, Event{
+			Type:      e.Type,
+			UserID:    e.UserID,
+			NoteID:    e.NoteID,
+			CreatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			log.Println("hook:", err)
+		}
+	}
+}
+
+// dispatch stores the deliveries of a note event for the webhooks that
+// receive it. A note that was removed since the event is sent without its
+// details.
+func (d *Dispatcher) dispatch(ctx context.Context, e Event) error {
+	data := Note{
+		ID:     e.NoteID,
+		UserID: e.UserID,
+	}
+
+	// Removed notes can no longer be read
+	if e.Type != noteevent.Deleted {
+		item, noRows, err := note.ByID(d.db, e.NoteID, e.UserID)
+		if err != nil && !noRows {
+			return err
+		}
+		data.Name = item.Name
+		data.Notebook = item.NotebookName.String
+		data.Version = item.Version
+	}
+
+	_, err := d.Enqueue(e.Type, e.UserID, data, e.CreatedAt)
+	return err
+}
+
+// deliver sends a delivery. A delivery that fails is queued again with a delay
+// until it reaches the maximum number of attempts. Deliveries of removed
+// webhooks are skipped.
+func (d *Dispatcher) deliver(ctx context.Context, p Delivery) error {
+	item, noRows, err := webhookdelivery.PendingByID(d.db, p.ID)
+	if noRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	status, err := Send(ctx, d.client, item.URL, item.Secret, item.Event, item.ID, []byte(item.Payload), time.Now())
+	now := time.Now()
+
+	// Let the job queue run it again when the queue stops so the attempt is
+	// not counted
+	if ctx.Err() == context.Canceled {
+		return ctx.Err()
+	}
+
+	if err == nil {
+		_, err = webhookdelivery.MarkDelivered(d.db, item.ID, status, now)
+		return err
+	}
+
+	attempts := int(item.Attempts) + 1
+	if attempts >= d.info.MaxAttempts {
+		_, err = webhookdelivery.MarkFailed(d.db, item.ID, status, err.Error(), nil, now)
+		return err
+	}
+
+	next := now.Add(jobs.Backoff(time.Duration(d.info.Backoff)*time.Second, attempts))
+	message := err.Error()
+	return note.Transaction(d.db, func(tx note.Connection) error {
+		if _, err := webhookdelivery.MarkFailed(tx, item.ID, status, message, &next, now); err != nil {
+			return err
+		}
+		return jobs.EnqueueAt(tx, Deliver, p, next)
+	})
+}
@@ -0,0 +1,133 @@
+package hook_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/hook"
+)
+
+// TestSign ensures a signature only matches the timestamp, body, and secret it
+// was made with and the timestamp is recent.
+func TestSign(t *testing.T) {
+	now := time.Unix(1467252000, 0)
+	timestamp := "1467252000"
+	body := []byte(`{"event":"ping"}`)
+	signature := hook.Sign("secret", timestamp, body)
+
+	if !hook.Verify("secret", timestamp, body, signature, now) {
+		t.Error("signature does not match the body")
+	}
+	if hook.Verify("other", timestamp, body, signature, now) {
+		t.Error("signature matches another secret")
+	}
+	if hook.Verify("secret", timestamp, []byte(`{"event":"note.created"}`), signature, now) {
+		t.Error("signature matches another body")
+	}
+	if hook.Verify("secret", "1467252001", body, signature, now) {
+		t.Error("signature matches another timestamp")
+	}
+	if hook.Verify("secret", timestamp, body, signature, now.Add(hook.Tolerance+time.Second)) {
+		t.Error("signature matches an old request")
+	}
+}
+
+// TestSend ensures a delivery is signed and a failed response is an error.
+func TestSend(t *testing.T) {
+	status := http.StatusNoContent
+	var received *http.Request
+	var body []byte
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	payload := []byte(`{"event":"note.created","data":{"id":"1"}}`)
+
+	now := time.Now()
+	code, err := hook.Send(context.Background(), ts.Client(), ts.URL, "secret", "note.created", 7, payload, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != http.StatusNoContent {
+		t.Errorf("got status %v", code)
+	}
+	if string(body) != string(payload) {
+		t.Errorf("got body %s", body)
+	}
+	if !hook.Verify("secret", received.Header.Get(hook.TimestampHeader), body, received.Header.Get(hook.SignatureHeader), now) {
+		t.Error("signature does not match the body")
+	}
+	if received.Header.Get(hook.EventHeader) != "note.created" {
+		t.Errorf("got event %v", received.Header.Get(hook.EventHeader))
+	}
+	if received.Header.Get(hook.DeliveryHeader) != "7" {
+		t.Errorf("got delivery %v", received.Header.Get(hook.DeliveryHeader))
+	}
+
+	status = http.StatusInternalServerError
+	code, err = hook.Send(context.Background(), ts.Client(), ts.URL, "secret", "note.created", 7, payload, now)
+	if err == nil {
+		t.Error("expected an error for a failed response")
+	}
+	if code != http.StatusInternalServerError {
+		t.Errorf("got status %v", code)
+	}
+}
+
+// TestSendCanceled ensures a request in flight stops when the context is
+// canceled.
+func TestSendCanceled(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := hook.Send(ctx, ts.Client(), ts.URL, "secret", hook.Ping, 1, []byte(`{}`), time.Now())
+	if err == nil || ctx.Err() != context.Canceled {
+		t.Errorf("got %v, want the request to be canceled", err)
+	}
+}
+
+// TestClient ensures the client does not connect to the internal network
+// unless loopback addresses are allowed.
+func TestClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	payload := []byte(`{"event":"ping"}`)
+
+	_, err := hook.Send(context.Background(), hook.NewClient(time.Second, false), ts.URL, "secret", hook.Ping, 1, payload, time.Now())
+	if err == nil || !strings.Contains(err.Error(), hook.ErrAddress.Error()) {
+		t.Errorf("got %v want %v", err, hook.ErrAddress)
+	}
+
+	for _, url := range []string{
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/",
+		"http://[::1]:1/",
+	} {
+		if _, err := hook.Send(context.Background(), hook.NewClient(time.Second, false), url, "secret", hook.Ping, 1, payload, time.Now()); err == nil || !strings.Contains(err.Error(), hook.ErrAddress.Error()) {
+			t.Errorf("%v: got %v want %v", url, err, hook.ErrAddress)
+		}
+	}
+
+	if _, err := hook.Send(context.Background(), hook.NewClient(time.Second, true), ts.URL, "secret", hook.Ping, 1, payload, time.Now()); err != nil {
+		t.Errorf("loopback should be allowed: %v", err)
+	}
+}
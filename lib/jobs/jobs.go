@@ -0,0 +1,309 @@
+// Package jobs runs work outside of the request goroutine. Jobs are stored in
+// the job table and taken by a pool of workers. Each type of job has a handler
+// that is registered at boot. A job that fails is retried with a delay that
+// doubles with each attempt and is marked as dead after too many attempts so
+// it can be inspected and queued again from the admin page.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/blue-jay/blueprint/model/job"
+)
+
+// Info holds the details for the job workers.
+type Info struct {
+	Enabled bool `json:"Enabled"`
+	// Workers is the number of jobs that run at the same time.
+	Workers int `json:"Workers"`
+	// Interval is the number of seconds between checks for new jobs when the
+	// queue is empty.
+	Interval int `json:"Interval"`
+	// Timeout is the number of seconds a job can run. A job that is still
+	// running after twice the timeout is taken by another worker because its
+	// worker is assumed to have stopped.
+	Timeout int `json:"Timeout"`
+	// MaxAttempts is the number of attempts before a job is marked as dead.
+	MaxAttempts int `json:"MaxAttempts"`
+	// Backoff is the number of seconds before the second attempt. The delay
+	// doubles after each attempt.
+	Backoff int `json:"Backoff"`
+}
+
+const (
+	// maxBackoff is the longest delay between attempts.
+	maxBackoff = 24 * time.Hour
+)
+
+var (
+	// ErrNoHandler is returned when a job has a type without a handler.
+	ErrNoHandler = errors.New("jobs: no handler for the job type")
+
+	// ErrReleased is returned when a job ran for so long that it was released
+	// and taken by another worker. The result of the first run is not stored.
+	ErrReleased = errors.New("jobs: the job was released and taken by another worker")
+
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// handler is a registered function and the type of its payload.
+type handler struct {
+	fn  reflect.Value
+	arg reflect.Type
+}
+
+// Queue runs the handlers of the jobs with a pool of workers.
+type Queue struct {
+	db       job.TxConnection
+	info     Info
+	name     string
+	handlers map[string]handler
+	mutex    sync.RWMutex
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// New returns a queue that is not started.
+func New(db job.TxConnection, info Info) *Queue {
+	if info.Workers <= 0 {
+		info.Workers = 2
+	}
+	if info.Interval <= 0 {
+		info.Interval = 5
+	}
+	if info.Timeout <= 0 {
+		info.Timeout = 300
+	}
+	if info.MaxAttempts <= 0 {
+		info.MaxAttempts = 5
+	}
+	if info.Backoff <= 0 {
+		info.Backoff = 30
+	}
+
+	host, _ := os.Hostname()
+
+	return &Queue{
+		db:       db,
+		info:     info,
+		name:     fmt.Sprintf("%v:%v", host, os.Getpid()),
+		handlers: make(map[string]handler),
+	}
+}
+
+// Register sets the handler for a type of job. The handler must be a function
+// of the form func(context.Context, T) error. The payload of the job is
+// decoded from JSON into a value of type T. Register panics if the handler
+// has a different form.
+func (q *Queue) Register(jobType string, fn interface{}) {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 2 || t.In(0) != contextType ||
+		t.NumOut() != 1 || t.Out(0) != errorType {
+		panic(fmt.Sprintf("jobs: handler for %v must be func(context.Context, T) error", jobType))
+	}
+
+	q.mutex.Lock()
+	q.handlers[jobType] = handler{fn: v, arg: t.In(1)}
+	q.mutex.Unlock()
+}
+
+// Run decodes the payload and calls the handler for the type of job. A panic
+// in the handler is returned as an error.
+func (q *Queue) Run(ctx context.Context, jobType string, payload []byte) (err error) {
+	q.mutex.RLock()
+	h, ok := q.handlers[jobType]
+	q.mutex.RUnlock()
+	if !ok {
+		return ErrNoHandler
+	}
+
+	arg := reflect.New(h.arg)
+	if err := json.Unmarshal(payload, arg.Interface()); err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("jobs: panic: %v", r)
+		}
+	}()
+
+	out := h.fn.Call([]reflect.Value{reflect.ValueOf(ctx), arg.Elem()})
+	err, _ = out[0].Interface().(error)
+	return err
+}
+
+// Enqueue stores a job of the type that runs as soon as a worker is free. The
+// payload is encoded as JSON.
+func Enqueue(db job.Connection, jobType string, payload interface{}) error {
+	return EnqueueAt(db, jobType, payload, time.Now())
+}
+
+// EnqueueAt stores a job of the type that runs at the time runAt. The payload
+// is encoded as JSON.
+func EnqueueAt(db job.Connection, jobType string, payload interface{}, runAt time.Time) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = job.Create(db, jobType, string(b), runAt)
+	return err
+}
+
+// Backoff returns the delay before the next attempt after the number of
+// attempts. The delay doubles after each attempt up to a day.
+func Backoff(base time.Duration, attempts int) time.Duration {
+	d := base
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// Start runs the workers in the background until Stop is called.
+func (q *Queue) Start() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	q.wg.Add(q.info.Workers + 1)
+	for i := 0; i < q.info.Workers; i++ {
+		go q.work(ctx, fmt.Sprintf("%v:%v", q.name, i))
+	}
+	go q.release(ctx)
+}
+
+// Stop cancels the context of the running jobs and waits for the workers to
+// finish.
+func (q *Queue) Stop() {
+	q.mutex.Lock()
+	cancel := q.cancel
+	q.cancel = nil
+	q.mutex.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	q.wg.Wait()
+}
+
+// work runs jobs until the context is done. It waits for the interval when the
+// queue is empty or there is an error.
+func (q *Queue) work(ctx context.Context, worker string) {
+	defer q.wg.Done()
+
+	for {
+		found, err := q.RunOnce(ctx, worker, time.Now())
+		if err != nil {
+			log.Println("jobs:", err)
+		}
+
+		if found && err == nil {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(q.info.Interval) * time.Second):
+		}
+	}
+}
+
+// release queues the jobs of stopped workers again on every interval until
+// the context is done.
+func (q *Queue) release(ctx context.Context) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(q.info.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		staleBefore := time.Now().Add(-2 * time.Duration(q.info.Timeout) * time.Second)
+		if _, err := job.ReleaseStale(q.db, staleBefore); err != nil {
+			log.Println("jobs:", err)
+		}
+	}
+}
+
+// RunOnce claims the next job that is ready at the time now for the worker and
+// runs it. Returns false if no job is ready.
+func (q *Queue) RunOnce(ctx context.Context, worker string, now time.Time) (bool, error) {
+	item, noRows, err := job.Claim(q.db, worker, now)
+	if noRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(q.info.Timeout)*time.Second)
+	err = q.Run(runCtx, item.Type, []byte(item.Payload))
+	cancel()
+
+	if err == nil {
+		return true, held(job.Finish(q.db, item.ID, worker))
+	}
+
+	finished := time.Now()
+
+	// The queue is stopping so run the job again without waiting
+	if ctx.Err() != nil {
+		return true, held(job.Retry(q.db, item.ID, worker, err.Error(), finished))
+	}
+
+	if err == ErrNoHandler || int(item.Attempts) >= q.info.MaxAttempts {
+		log.Printf("jobs: job %v of type %v is dead: %v\n", item.ID, item.Type, err)
+		return true, held(job.Bury(q.db, item.ID, worker, err.Error(), finished))
+	}
+
+	next := finished.Add(Backoff(time.Duration(q.info.Backoff)*time.Second, int(item.Attempts)))
+	return true, held(job.Retry(q.db, item.ID, worker, err.Error(), next))
+}
+
+// held returns ErrReleased when the update of a job changed no rows because
+// the job was released and taken by another worker.
+func held(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return ErrReleased
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package jobs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/jobs"
+)
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+// TestRun ensures the payload is decoded into the type of the handler and a
+// panic is returned as an error.
+func TestRun(t *testing.T) {
+	q := jobs.New(nil, jobs.Info{})
+
+	var received string
+	q.Register("greet", func(ctx context.Context, g greeting) error {
+		received = g.Name
+		return nil
+	})
+	q.Register("panic", func(ctx context.Context, g greeting) error {
+		panic("boom")
+	})
+
+	if err := q.Run(context.Background(), "greet", []byte(`{"name":"John"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if received != "John" {
+		t.Errorf("got %q", received)
+	}
+
+	if err := q.Run(context.Background(), "panic", []byte(`{}`)); err == nil {
+		t.Error("expected an error from the panic")
+	}
+
+	if err := q.Run(context.Background(), "missing", []byte(`{}`)); err != jobs.ErrNoHandler {
+		t.Errorf("got %v, want %v", err, jobs.ErrNoHandler)
+	}
+}
+
+// TestRegister ensures a handler with the wrong form is rejected.
+func TestRegister(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+
+	jobs.New(nil, jobs.Info{}).Register("bad", func(g greeting) {})
+}
+
+// TestBackoff ensures the delay doubles after each attempt up to a day.
+func TestBackoff(t *testing.T) {
+	base := 30 * time.Second
+	for attempts, expected := range map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		4:  4 * time.Minute,
+		30: 24 * time.Hour,
+	} {
+		if received := jobs.Backoff(base, attempts); received != expected {
+			t.Errorf("attempt %v: got %v, want %v", attempts, received, expected)
+		}
+	}
+}
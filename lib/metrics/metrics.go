@@ -0,0 +1,216 @@
+// Package metrics collects the metrics of the application and serves them in
+// the Prometheus text format. The HTTP requests are labeled by the route
+// pattern instead of the raw URL so the number of series stays small.
+package metrics
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// namespace is the prefix of every metric name.
+	namespace = "blueprint"
+	// Unmatched is the route of the requests that did not match a route.
+	Unmatched = "unmatched"
+)
+
+// Info holds the details for the metrics endpoint.
+type Info struct {
+	// Enabled serves the metrics at /metrics.
+	Enabled bool `json:"Enabled"`
+	// AllowIPs is a list of IP addresses and CIDR ranges that can read the
+	// metrics without a token.
+	AllowIPs []string `json:"AllowIPs"`
+	// Token is the bearer token that can read the metrics from any address.
+	// Tokens are not accepted when it is empty.
+	Token string `json:"Token"`
+}
+
+// Metrics holds the metrics of one application and the registry they are
+// served from. Each application has its own so more than one can run in a
+// process.
+type Metrics struct {
+	// Registry holds the metrics of the application.
+	Registry *prometheus.Registry
+	// Requests counts the HTTP requests.
+	Requests *prometheus.CounterVec
+	// Duration measures the latency of the HTTP requests.
+	Duration *prometheus.HistogramVec
+	// Logins counts the login attempts by result.
+	Logins *prometheus.CounterVec
+	// Registrations counts the accounts that are created.
+	Registrations prometheus.Counter
+	// NotesCreated counts the notes that are added.
+	NotesCreated prometheus.Counter
+	// Panics counts the panics recovered from the handlers.
+	Panics prometheus.Counter
+}
+
+// New returns the metrics of an application in a new registry.
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		Requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by route, method, and status.",
+		}, []string{"route", "method", "status"}),
+		Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the HTTP requests by route, method, and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		Logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Number of login attempts by result.",
+		}, []string{"result"}),
+		Registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "registrations_total",
+			Help:      "Number of accounts created.",
+		}),
+		NotesCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "notes_created_total",
+			Help:      "Number of notes added.",
+		}),
+		Panics: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "panics_total",
+			Help:      "Number of panics recovered from the HTTP handlers.",
+		}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.Requests,
+		m.Duration,
+		m.Logins,
+		m.Registrations,
+		m.NotesCreated,
+		m.Panics,
+	)
+
+	// Start the login results at zero so rates work from the first attempt
+	for _, result := range []string{"success", "failure", "inactive"} {
+		m.Logins.WithLabelValues(result)
+	}
+
+	return m
+}
+
+// RegisterDB adds the connection pool stats of the database.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	return m.Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler returns the handler that writes the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+}
+
+// Observe records a request to the route.
+func (m *Metrics) Observe(route string, method string, status int, d time.Duration) {
+	labels := []string{route, Method(method), strconv.Itoa(status)}
+	m.Requests.WithLabelValues(labels...).Inc()
+	m.Duration.WithLabelValues(labels...).Observe(d.Seconds())
+}
+
+// Method returns the HTTP method or "other" for a method that is not
+// standard so clients cannot add series.
+func Method(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return m
+	}
+	return "other"
+}
+
+// routeKey is the context key of the route of a request.
+type routeKey struct{}
+
+// matched is the route pattern and the method of the route that matched a
+// request.
+type matched struct {
+	pattern string
+	method  string
+}
+
+// WithRoute returns a copy of the context that can hold the route of the
+// request. The route is set with SetRoute and read with RouteOf.
+func WithRoute(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routeKey{}, &matched{})
+}
+
+// SetRoute records the route pattern that matched the request and the method
+// of the request. The method is recorded because it can be changed by the
+// middleware after the request is instrumented, like a form that sends a
+// PATCH as a POST. It does nothing when the context of the request was not
+// made with WithRoute.
+func SetRoute(r *http.Request, pattern string) {
+	if m, ok := r.Context().Value(routeKey{}).(*matched); ok {
+		m.pattern = pattern
+		m.method = r.Method
+	}
+}
+
+// RouteOf returns the route pattern and the method that were recorded for
+// the request. It returns Unmatched and the method when no route matched.
+// The pattern always comes from the routes that were registered so clients
+// cannot add series.
+func RouteOf(ctx context.Context, method string) (string, string) {
+	if m, ok := ctx.Value(routeKey{}).(*matched); ok && len(m.pattern) > 0 {
+		return m.pattern, m.method
+	}
+	return Unmatched, method
+}
+
+// Allowed returns true if the request has the bearer token or comes from an
+// allowed address.
+func (i Info) Allowed(r *http.Request) bool {
+	if len(i.Token) > 0 {
+		auth := r.Header.Get("Authorization")
+		if strings.HasPrefix(auth, "Bearer ") &&
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(i.Token)) == 1 {
+			return true
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, a := range i.AllowIPs {
+		if strings.Contains(a, "/") {
+			if _, n, err := net.ParseCIDR(a); err == nil && n.Contains(ip) {
+				return true
+			}
+		} else if allowed := net.ParseIP(a); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,125 @@
+package metrics_test
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/metrics"
+)
+
+// TestRoute ensures the recorded pattern and method are returned and a
+// request without them is unmatched.
+func TestRoute(t *testing.T) {
+	r := httptest.NewRequest("POST", "/notepad/view/12?:_name=x", nil)
+	if got, _ := metrics.RouteOf(r.Context(), r.Method); got != metrics.Unmatched {
+		t.Errorf("without a context got %v, want %v", got, metrics.Unmatched)
+	}
+
+	// SetRoute does nothing without a context that can hold the route
+	metrics.SetRoute(r, "/notepad/view/:id")
+	if got, _ := metrics.RouteOf(r.Context(), r.Method); got != metrics.Unmatched {
+		t.Errorf("without WithRoute got %v, want %v", got, metrics.Unmatched)
+	}
+
+	r = r.WithContext(metrics.WithRoute(r.Context()))
+	if got, method := metrics.RouteOf(r.Context(), r.Method); got != metrics.Unmatched || method != "POST" {
+		t.Errorf("before SetRoute got %v %v, want %v POST", method, got, metrics.Unmatched)
+	}
+
+	// The method changed by the middleware is recorded with the route
+	r.Method = "PATCH"
+	metrics.SetRoute(r, "/notepad/view/:id")
+	if got, method := metrics.RouteOf(r.Context(), "POST"); got != "/notepad/view/:id" || method != "PATCH" {
+		t.Errorf("got %v %v, want PATCH /notepad/view/:id", method, got)
+	}
+}
+
+// TestMethod ensures unknown methods share a label.
+func TestMethod(t *testing.T) {
+	if got := metrics.Method("PATCH"); got != "PATCH" {
+		t.Errorf("got %v, want PATCH", got)
+	}
+	if got := metrics.Method("BREW"); got != "other" {
+		t.Errorf("got %v, want other", got)
+	}
+}
+
+// TestAllowed ensures the token or an allowed address is required.
+func TestAllowed(t *testing.T) {
+	info := metrics.Info{
+		AllowIPs: []string{"127.0.0.1", "10.0.0.0/8", "bad"},
+		Token:    "secret",
+	}
+
+	tests := []struct {
+		remote   string
+		auth     string
+		expected bool
+	}{
+		{"127.0.0.1:5000", "", true},
+		{"10.1.2.3:5000", "", true},
+		{"192.168.1.1:5000", "", false},
+		{"192.168.1.1:5000", "Bearer secret", true},
+		{"192.168.1.1:5000", "Bearer wrong", false},
+		{"192.168.1.1:5000", "secret", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/metrics", nil)
+		r.RemoteAddr = tt.remote
+		if len(tt.auth) > 0 {
+			r.Header.Set("Authorization", tt.auth)
+		}
+		if got := info.Allowed(r); got != tt.expected {
+			t.Errorf("Allowed(%v, %q) got %v, want %v", tt.remote, tt.auth, got, tt.expected)
+		}
+	}
+
+	// An empty token is never accepted
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	r.RemoteAddr = "192.168.1.1:5000"
+	r.Header.Set("Authorization", "Bearer ")
+	if (metrics.Info{}).Allowed(r) {
+		t.Error("empty token was accepted")
+	}
+}
+
+// TestHandler ensures the requests are written in the text format.
+func TestHandler(t *testing.T) {
+	m := metrics.New()
+	m.Observe("/notepad/view/:id", "GET", 200, 50*time.Millisecond)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := w.Body.String()
+	for _, s := range []string{
+		`blueprint_http_requests_total{method="GET",route="/notepad/view/:id",status="200"} 1`,
+		`blueprint_http_request_duration_seconds_bucket{method="GET",route="/notepad/view/:id",status="200",le="0.05"} 1`,
+		`blueprint_logins_total{result="success"} 0`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, s) {
+			t.Errorf("metrics do not contain %v", s)
+		}
+	}
+}
+
+// TestRegisterDB ensures each application can add a database with the same
+// name to its own registry.
+func TestRegisterDB(t *testing.T) {
+	a, b := metrics.New(), metrics.New()
+
+	if err := a.RegisterDB(new(sql.DB), "blueprint"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RegisterDB(new(sql.DB), "blueprint"); err != nil {
+		t.Errorf("second application got %v", err)
+	}
+	if err := a.RegisterDB(new(sql.DB), "blueprint"); err == nil {
+		t.Error("same name was added twice to one registry")
+	}
+}
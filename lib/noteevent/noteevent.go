@@ -0,0 +1,84 @@
+// Package noteevent publishes the changes to the notes of a user.
+package noteevent
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/blue-jay/blueprint/lib/pubsub"
+)
+
+const (
+	// Created is the type of event when a note is added or restored.
+	Created = "note.created"
+	// Updated is the type of event when a note is changed.
+	Updated = "note.updated"
+	// Deleted is the type of event when a note is removed.
+	Deleted = "note.deleted"
+)
+
+// Event is a change to a note.
+type Event struct {
+	Type   string `json:"type"`
+	NoteID string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+// Bus passes the events of one application to its handlers. Each
+// application has its own so more than one can run in a process.
+type Bus struct {
+	handlers []func(Event)
+	mutex    sync.RWMutex
+}
+
+// New returns a bus without handlers.
+func New() *Bus {
+	return &Bus{}
+}
+
+// Handle calls the function for every event that is published. The function
+// is called before Publish returns so it should not block for long.
+func (b *Bus) Handle(fn func(Event)) {
+	b.mutex.Lock()
+	b.handlers = append(b.handlers, fn)
+	b.mutex.Unlock()
+}
+
+// Topic returns the topic of the events for the notes of a user.
+func Topic(userID string) string {
+	return "notes." + userID
+}
+
+// Publish sends an event of the type for each note to the handlers and to the
+// hub. Errors are logged because the change was already stored. Nothing is
+// sent to the hub when it is nil.
+func (b *Bus) Publish(h *pubsub.Hub, eventType string, userID string, IDs ...string) {
+	b.mutex.RLock()
+	fns := b.handlers
+	b.mutex.RUnlock()
+
+	for _, ID := range IDs {
+		e := Event{
+			Type:   eventType,
+			NoteID: ID,
+			UserID: userID,
+		}
+
+		for _, fn := range fns {
+			fn(e)
+		}
+
+		if h == nil {
+			continue
+		}
+
+		data, err := json.Marshal(e)
+		if err == nil {
+			err = h.Publish(Topic(userID), data)
+		}
+		if err != nil {
+			log.Println("noteevent:", err)
+		}
+	}
+}
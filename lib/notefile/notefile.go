@@ -0,0 +1,501 @@
+// Package notefile reads and writes notes as JSON, CSV, or Markdown files in a
+// ZIP archive.
+package notefile
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// JSON is a file with an array of notes.
+	JSON = "json"
+	// CSV is a file with a header row and one note per row.
+	CSV = "csv"
+	// MarkdownZIP is a ZIP archive with one Markdown file per note.
+	MarkdownZIP = "md.zip"
+
+	// maxEntry is the largest Markdown file in an archive: the longest name
+	// plus room for the front matter.
+	maxEntry = 65535 + 4096
+	// maxArchive is the most that is decompressed from one archive.
+	maxArchive = 50 << 20
+)
+
+var (
+	// header is the first row of a CSV file.
+	header = []string{"external_id", "name", "notebook", "tags", "created_at", "updated_at"}
+
+	// ErrFormat is returned when the format is not supported.
+	ErrFormat = errors.New("format is not supported")
+
+	// ErrArchiveSize is returned when the files in an archive are too large
+	// in total.
+	ErrArchiveSize = fmt.Errorf("archive is larger than %v bytes when decompressed", maxArchive)
+
+	// errEntrySize is set on a row when the file is too large.
+	errEntrySize = fmt.Errorf("file is larger than %v bytes", maxEntry)
+)
+
+// Record is a note in a file.
+type Record struct {
+	ExternalID string     `json:"external_id"`
+	Name       string     `json:"name"`
+	Notebook   string     `json:"notebook"`
+	Tags       []string   `json:"tags"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// Row is a record read from a file. Line is the row number in a CSV file, the
+// position in a JSON array, or the file name in a ZIP archive.
+type Row struct {
+	Line   string
+	Record Record
+	Err    error
+}
+
+// Valid returns true if the format is supported.
+func Valid(format string) bool {
+	return format == JSON || format == CSV || format == MarkdownZIP
+}
+
+// ContentType returns the MIME type of the format.
+func ContentType(format string) string {
+	switch format {
+	case JSON:
+		return "application/json; charset=utf-8"
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case MarkdownZIP:
+		return "application/zip"
+	}
+	return "application/octet-stream"
+}
+
+// Validate returns an error if a record cannot be stored.
+func Validate(r Record) error {
+	switch {
+	case len(strings.TrimSpace(r.Name)) == 0:
+		return errors.New("name is required")
+	case len(r.Name) > 65535:
+		return errors.New("name is longer than 65535 bytes")
+	case !utf8.ValidString(r.Name):
+		return errors.New("name is not valid UTF-8")
+	case len(r.ExternalID) > 100:
+		return errors.New("external_id is longer than 100 characters")
+	case utf8.RuneCountInString(r.Notebook) > 100:
+		return errors.New("notebook is longer than 100 characters")
+	}
+
+	for _, t := range r.Tags {
+		if utf8.RuneCountInString(t) > 50 {
+			return fmt.Errorf("tag '%v' is longer than 50 characters", t)
+		}
+	}
+
+	return nil
+}
+
+// *****************************************************************************
+// Writer
+// *****************************************************************************
+
+// Writer writes records to a file one at a time so large exports do not need
+// to be held in memory.
+type Writer interface {
+	Write(r Record) error
+	Close() error
+}
+
+// NewWriter returns a writer for the format.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case JSON:
+		return &jsonWriter{w: w}, nil
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case MarkdownZIP:
+		return &zipWriter{w: zip.NewWriter(w), names: make(map[string]bool)}, nil
+	}
+	return nil, ErrFormat
+}
+
+// jsonWriter writes an array of records.
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonWriter) Write(r Record) error {
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "[\n"
+	}
+	j.count++
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(j.w, "%v%s", sep, b)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	if j.count == 0 {
+		_, err := io.WriteString(j.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+// csvWriter writes a header row and then one row per record.
+type csvWriter struct {
+	w     *csv.Writer
+	count int
+}
+
+func (c *csvWriter) Write(r Record) error {
+	if c.count == 0 {
+		if err := c.w.Write(header); err != nil {
+			return err
+		}
+	}
+	c.count++
+
+	err := c.w.Write([]string{
+		r.ExternalID,
+		r.Name,
+		r.Notebook,
+		strings.Join(r.Tags, ","),
+		formatTime(r.CreatedAt),
+		formatTime(r.UpdatedAt),
+	})
+	c.w.Flush()
+	if err != nil {
+		return err
+	}
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	if c.count == 0 {
+		c.w.Write(header)
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// zipWriter writes one Markdown file per record with the metadata in a front
+// matter block.
+type zipWriter struct {
+	w     *zip.Writer
+	names map[string]bool
+}
+
+func (z *zipWriter) Write(r Record) error {
+	// Make sure each file name is unique
+	name := safeName(r.ExternalID)
+	for i := 2; z.names[name]; i++ {
+		name = fmt.Sprintf("%v-%v", safeName(r.ExternalID), i)
+	}
+	z.names[name] = true
+
+	f, err := z.w.Create(name + ".md")
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "external_id: %v\n", r.ExternalID)
+	fmt.Fprintf(&b, "notebook: %v\n", r.Notebook)
+	fmt.Fprintf(&b, "tags: %v\n", strings.Join(r.Tags, ","))
+	fmt.Fprintf(&b, "created_at: %v\n", formatTime(r.CreatedAt))
+	fmt.Fprintf(&b, "updated_at: %v\n", formatTime(r.UpdatedAt))
+	b.WriteString("---\n")
+	b.WriteString(r.Name)
+
+	_, err = f.Write(b.Bytes())
+	return err
+}
+
+func (z *zipWriter) Close() error {
+	return z.w.Close()
+}
+
+// *****************************************************************************
+// Reader
+// *****************************************************************************
+
+// Read parses every record in a file. An error is only returned if the file
+// cannot be read at all; problems with a single record are stored in the Err
+// field of its row.
+func Read(data []byte, format string) ([]Row, error) {
+	var rows []Row
+	var err error
+
+	switch format {
+	case JSON:
+		rows, err = readJSON(data)
+	case CSV:
+		rows, err = readCSV(data)
+	case MarkdownZIP:
+		rows, err = readZIP(data)
+	default:
+		return nil, ErrFormat
+	}
+
+	// Validate the records that could be parsed
+	for i := range rows {
+		if rows[i].Err == nil {
+			rows[i].Err = Validate(rows[i].Record)
+		}
+	}
+
+	return rows, err
+}
+
+// readJSON parses an array of records.
+func readJSON(data []byte) ([]Row, error) {
+	var rows []Row
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	for i, m := range raw {
+		row := Row{Line: fmt.Sprintf("%v", i+1)}
+		row.Err = json.Unmarshal(m, &row.Record)
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// readCSV parses a file with a header row. The columns can be in any order,
+// only the name column is required.
+func readCSV(data []byte) ([]Row, error) {
+	var rows []Row
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+
+	head, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, h := range head {
+		columns[strings.TrimSpace(strings.ToLower(h))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("header row has no name column")
+	}
+
+	for line := 2; ; line++ {
+		fields, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		row := Row{Line: fmt.Sprintf("%v", line)}
+		if err != nil {
+			row.Err = err
+			rows = append(rows, row)
+			if _, ok := err.(*csv.ParseError); ok {
+				continue
+			}
+			return rows, err
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(fields) {
+				return fields[i]
+			}
+			return ""
+		}
+
+		row.Record = Record{
+			ExternalID: strings.TrimSpace(field("external_id")),
+			Name:       field("name"),
+			Notebook:   strings.TrimSpace(field("notebook")),
+			Tags:       splitTags(field("tags")),
+		}
+		row.Record.CreatedAt, row.Err = parseTime(field("created_at"))
+		if row.Err == nil {
+			row.Record.UpdatedAt, row.Err = parseTime(field("updated_at"))
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// readZIP parses every Markdown file in an archive. The size in the header of
+// a file cannot be trusted so each file is read up to the limit.
+func readZIP(data []byte) ([]Row, error) {
+	var rows []Row
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, f := range z.File {
+		if f.FileInfo().IsDir() || path.Ext(f.Name) != ".md" {
+			continue
+		}
+
+		row := Row{Line: f.Name}
+		if f.UncompressedSize64 > maxEntry {
+			row.Err = errEntrySize
+			rows = append(rows, row)
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			row.Err = err
+			rows = append(rows, row)
+			continue
+		}
+
+		b, err := ioutil.ReadAll(io.LimitReader(rc, maxEntry+1))
+		rc.Close()
+
+		total += int64(len(b))
+		if total > maxArchive {
+			return nil, ErrArchiveSize
+		}
+
+		switch {
+		case err != nil:
+			row.Err = err
+		case len(b) > maxEntry:
+			row.Err = errEntrySize
+		default:
+			row.Record, row.Err = parseMarkdown(b)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseMarkdown parses a file with an optional front matter block.
+func parseMarkdown(b []byte) (Record, error) {
+	var r Record
+	text := strings.Replace(string(b), "\r\n", "\n", -1)
+
+	if !strings.HasPrefix(text, "---\n") {
+		r.Name = text
+		return r, nil
+	}
+
+	end := strings.Index(text[4:], "\n---\n")
+	if end < 0 {
+		return r, errors.New("front matter is not closed")
+	}
+	meta := text[4 : 4+end]
+	r.Name = text[4+end+5:]
+
+	var err error
+	s := bufio.NewScanner(strings.NewReader(meta))
+	for s.Scan() {
+		parts := strings.SplitN(s.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value := strings.TrimSpace(parts[1])
+		switch strings.TrimSpace(parts[0]) {
+		case "external_id":
+			r.ExternalID = value
+		case "notebook":
+			r.Notebook = value
+		case "tags":
+			r.Tags = splitTags(value)
+		case "created_at":
+			if r.CreatedAt, err = parseTime(value); err != nil {
+				return r, err
+			}
+		case "updated_at":
+			if r.UpdatedAt, err = parseTime(value); err != nil {
+				return r, err
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// *****************************************************************************
+// Helpers
+// *****************************************************************************
+
+// splitTags returns the tags from a comma separated list.
+func splitTags(s string) []string {
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); len(t) > 0 {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// formatTime returns the time in RFC 3339 format or an empty string.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// parseTime returns the time from a string in RFC 3339 format or nil if the
+// string is empty.
+func parseTime(s string) (*time.Time, error) {
+	if len(strings.TrimSpace(s)) == 0 {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("time '%v' is not in RFC 3339 format", s)
+	}
+	return &t, nil
+}
+
+// safeName returns a file name that only contains letters, numbers, dashes,
+// and underscores.
+func safeName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+
+	if len(name) == 0 {
+		return "note"
+	}
+	return name
+}
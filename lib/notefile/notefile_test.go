@@ -0,0 +1,150 @@
+package notefile_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/notefile"
+)
+
+// TestRoundTrip ensures records are read back the same way they are written.
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2016, 6, 30, 2, 0, 0, 0, time.UTC)
+
+	records := []notefile.Record{
+		{
+			ExternalID: "abc",
+			Name:       "First line\nsecond line, with a comma",
+			Notebook:   "Runbooks",
+			Tags:       []string{"ops", "db"},
+			CreatedAt:  &created,
+		},
+		{
+			ExternalID: "def",
+			Name:       "Another note.",
+		},
+	}
+
+	for _, format := range []string{notefile.JSON, notefile.CSV, notefile.MarkdownZIP} {
+		var b bytes.Buffer
+		w, err := notefile.NewWriter(&b, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, r := range records {
+			if err := w.Write(r); err != nil {
+				t.Fatalf("%v: could not write record: %v", format, err)
+			}
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatalf("%v: could not close writer: %v", format, err)
+		}
+
+		rows, err := notefile.Read(b.Bytes(), format)
+		if err != nil {
+			t.Fatalf("%v: could not read file: %v", format, err)
+		}
+
+		if len(rows) != len(records) {
+			t.Fatalf("%v: got %v rows want %v", format, len(rows), len(records))
+		}
+
+		for i, row := range rows {
+			if row.Err != nil {
+				t.Errorf("%v: row %v has error: %v", format, row.Line, row.Err)
+			}
+			if !reflect.DeepEqual(row.Record, records[i]) {
+				t.Errorf("%v: row %v\n got: %#v\nwant: %#v", format, row.Line, row.Record, records[i])
+			}
+		}
+	}
+}
+
+// TestReadErrors ensures invalid rows are reported without stopping the read.
+func TestReadErrors(t *testing.T) {
+	data := []byte("name,external_id,created_at\n" +
+		"Valid note,a,\n" +
+		",b,\n" +
+		"Bad time,c,yesterday\n")
+
+	rows, err := notefile.Read(data, notefile.CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("got %v rows want 3", len(rows))
+	}
+
+	if rows[0].Err != nil {
+		t.Errorf("row %v should be valid: %v", rows[0].Line, rows[0].Err)
+	}
+
+	for _, row := range rows[1:] {
+		if row.Err == nil {
+			t.Errorf("row %v should have an error", row.Line)
+		}
+	}
+}
+
+// TestFormat ensures unknown formats are rejected.
+func TestFormat(t *testing.T) {
+	if notefile.Valid("xml") {
+		t.Error("xml should not be a valid format")
+	}
+
+	if _, err := notefile.NewWriter(&bytes.Buffer{}, "xml"); err != notefile.ErrFormat {
+		t.Errorf("got %v want %v", err, notefile.ErrFormat)
+	}
+}
+
+// archive returns a ZIP archive with one file per size. Each file is filled
+// with a letter so it compresses to almost nothing.
+func archive(t *testing.T, sizes ...int) []byte {
+	buf := &bytes.Buffer{}
+	z := zip.NewWriter(buf)
+	for i, size := range sizes {
+		f, err := z.Create(fmt.Sprintf("%v.md", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = f.Write(bytes.Repeat([]byte("a"), size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestReadZIPLimits ensures large files in an archive are not decompressed.
+func TestReadZIPLimits(t *testing.T) {
+	rows, err := notefile.Read(archive(t, 10, 1<<20), notefile.MarkdownZIP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %v rows want 2", len(rows))
+	}
+	if rows[0].Err != nil {
+		t.Errorf("row %v should be valid: %v", rows[0].Line, rows[0].Err)
+	}
+	if rows[1].Err == nil {
+		t.Errorf("row %v should be too large", rows[1].Line)
+	}
+
+	sizes := make([]int, 1000)
+	for i := range sizes {
+		sizes[i] = 65535
+	}
+	if _, err = notefile.Read(archive(t, sizes...), notefile.MarkdownZIP); err != notefile.ErrArchiveSize {
+		t.Errorf("got %v want %v", err, notefile.ErrArchiveSize)
+	}
+}
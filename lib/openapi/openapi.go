@@ -0,0 +1,220 @@
+// Package openapi builds an OpenAPI 3 document. Schemas are generated from Go
+// struct definitions using their json tags.
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI version of the document.
+const Version = "3.0.3"
+
+// Document is the root of an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem holds the operations of a path by lowercase HTTP method.
+type PathItem map[string]Operation
+
+// Operation describes a single route.
+type Operation struct {
+	OperationID string              `json:"operationId,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter describes a path or query string parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// Response describes a response for a status code.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas that are referenced by the operations.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema describes a value.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// New returns an empty document.
+func New(info Info) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+		},
+	}
+}
+
+// Path converts a router path like /notes/:id to an OpenAPI path like
+// /notes/{id}.
+func Path(routerPath string) string {
+	parts := strings.Split(routerPath, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") {
+			parts[i] = "{" + p[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Add adds the operation for the method and router path.
+func (d *Document) Add(method string, routerPath string, op Operation) {
+	p := Path(routerPath)
+	if d.Paths[p] == nil {
+		d.Paths[p] = make(PathItem)
+	}
+	d.Paths[p][strings.ToLower(method)] = op
+}
+
+// Has returns true if the document has an operation for the method and router
+// path.
+func (d *Document) Has(method string, routerPath string) bool {
+	_, ok := d.Paths[Path(routerPath)][strings.ToLower(method)]
+	return ok
+}
+
+// JSON returns the content of a JSON body with the schema of v.
+func (d *Document) JSON(v interface{}) map[string]MediaType {
+	return map[string]MediaType{
+		"application/json": {Schema: d.Schema(v)},
+	}
+}
+
+// Schema returns the schema of v. A struct is added to the components and a
+// reference to it is returned.
+func (d *Document) Schema(v interface{}) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+// timeType is the type of a time which is written as a string.
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf returns the schema of a type.
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := d.schemaOf(t.Elem())
+		if len(s.Ref) > 0 {
+			return s
+		}
+		s.Nullable = true
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		return d.structRef(t)
+	}
+
+	// Interfaces can hold any value
+	return &Schema{}
+}
+
+// structRef adds the schema of a struct to the components and returns a
+// reference to it.
+func (d *Document) structRef(t reflect.Type) *Schema {
+	ref := &Schema{Ref: "#/components/schemas/" + t.Name()}
+	if _, ok := d.Components.Schemas[t.Name()]; ok {
+		return ref
+	}
+
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	// Add before the fields so recursive types do not loop forever
+	d.Components.Schemas[t.Name()] = s
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+
+		name := f.Name
+		if tag := f.Tag.Get("json"); len(tag) > 0 {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; len(n) > 0 {
+				name = n
+			}
+		}
+
+		s.Properties[name] = d.schemaOf(f.Type)
+	}
+
+	return ref
+}
@@ -0,0 +1,67 @@
+package openapi_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/openapi"
+)
+
+type child struct {
+	Name string `json:"name"`
+}
+
+type parent struct {
+	ID       uint32            `json:"id"`
+	Note     *string           `json:"note"`
+	Children []child           `json:"children"`
+	Meta     map[string]string `json:"meta"`
+	Created  time.Time         `json:"created_at"`
+	Skipped  string            `json:"-"`
+	hidden   string
+}
+
+// TestSchema ensures structs are converted using their json tags.
+func TestSchema(t *testing.T) {
+	d := openapi.New(openapi.Info{Title: "Test", Version: "1"})
+
+	ref := d.Schema(parent{})
+	if ref.Ref != "#/components/schemas/parent" {
+		t.Fatalf("wrong reference: %v", ref.Ref)
+	}
+
+	s := d.Components.Schemas["parent"]
+	if len(s.Properties) != 5 {
+		t.Errorf("got %v properties want 5: %v", len(s.Properties), s.Properties)
+	}
+
+	if p := s.Properties["id"]; p.Type != "integer" {
+		t.Errorf("wrong id type: %v", p.Type)
+	}
+	if p := s.Properties["note"]; p.Type != "string" || !p.Nullable {
+		t.Errorf("wrong note schema: %+v", p)
+	}
+	if p := s.Properties["children"]; p.Type != "array" || p.Items.Ref != "#/components/schemas/child" {
+		t.Errorf("wrong children schema: %+v", p)
+	}
+	if p := s.Properties["created_at"]; p.Format != "date-time" {
+		t.Errorf("wrong created_at schema: %+v", p)
+	}
+	if _, ok := d.Components.Schemas["child"]; !ok {
+		t.Error("child schema was not added")
+	}
+}
+
+// TestPath ensures router parameters are converted.
+func TestPath(t *testing.T) {
+	d := openapi.New(openapi.Info{Title: "Test", Version: "1"})
+	d.Add("GET", "/notes/:id", openapi.Operation{})
+
+	if _, ok := d.Paths["/notes/{id}"]["get"]; !ok {
+		t.Errorf("path was not converted: %v", d.Paths)
+	}
+
+	if !d.Has("GET", "/notes/:id") || d.Has("POST", "/notes/:id") {
+		t.Error("wrong result from Has")
+	}
+}
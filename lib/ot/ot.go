@@ -0,0 +1,274 @@
+// Package ot merges concurrent changes to a text with operational transform.
+//
+// An operation is a list of components that walk the text from the start: a
+// retain (positive number) keeps characters, a delete (negative number)
+// removes characters, and an insert (string) adds text. Lengths are in UTF-16
+// code units so they match the length of JavaScript strings. The JSON form of
+// an operation is an array like [5, "abc", -3].
+package ot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+var (
+	// ErrLength is returned when an operation does not cover the whole text.
+	ErrLength = errors.New("operation length does not match the text")
+)
+
+// Component is a single retain, delete, or insert. N is positive for a retain
+// and negative for a delete. Insert is set for an insert.
+type Component struct {
+	N      int
+	Insert string
+}
+
+// Op is an operation on a text.
+type Op []Component
+
+// length returns the length of a string in UTF-16 code units.
+func length(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// Retain adds a retain of n characters. Like append, the result must be
+// assigned because the receiver may be changed.
+func (o Op) Retain(n int) Op {
+	if n <= 0 {
+		return o
+	}
+	if l := len(o); l > 0 && o[l-1].N > 0 {
+		o[l-1].N += n
+		return o
+	}
+	return append(o, Component{N: n})
+}
+
+// Delete adds a delete of n characters.
+func (o Op) Delete(n int) Op {
+	if n <= 0 {
+		return o
+	}
+	if l := len(o); l > 0 && o[l-1].N < 0 {
+		o[l-1].N -= n
+		return o
+	}
+	return append(o, Component{N: -n})
+}
+
+// Insert adds an insert of the text. An insert is always placed before a
+// delete at the same position so equal operations have the same form.
+func (o Op) Insert(s string) Op {
+	if len(s) == 0 {
+		return o
+	}
+
+	l := len(o)
+	if l > 0 && len(o[l-1].Insert) > 0 {
+		o[l-1].Insert += s
+		return o
+	}
+
+	if l > 0 && o[l-1].N < 0 {
+		if l > 1 && len(o[l-2].Insert) > 0 {
+			o[l-2].Insert += s
+			return o
+		}
+		o = append(o, o[l-1])
+		o[l-1] = Component{Insert: s}
+		return o
+	}
+
+	return append(o, Component{Insert: s})
+}
+
+// BaseLen returns the length of the text the operation applies to.
+func (o Op) BaseLen() int {
+	n := 0
+	for _, c := range o {
+		if c.N > 0 {
+			n += c.N
+		} else {
+			n -= c.N
+		}
+	}
+	return n
+}
+
+// TargetLen returns the length of the text after the operation is applied.
+func (o Op) TargetLen() int {
+	n := 0
+	for _, c := range o {
+		if c.N > 0 {
+			n += c.N
+		} else if len(c.Insert) > 0 {
+			n += length(c.Insert)
+		}
+	}
+	return n
+}
+
+// IsNoop returns true if the operation does not change the text.
+func (o Op) IsNoop() bool {
+	return len(o) == 0 || (len(o) == 1 && o[0].N > 0)
+}
+
+// Apply returns the text after the operation is applied.
+func (o Op) Apply(text string) (string, error) {
+	src := utf16.Encode([]rune(text))
+	if o.BaseLen() != len(src) {
+		return "", ErrLength
+	}
+
+	dst := make([]uint16, 0, o.TargetLen())
+	i := 0
+	for _, c := range o {
+		switch {
+		case c.N > 0:
+			dst = append(dst, src[i:i+c.N]...)
+			i += c.N
+		case c.N < 0:
+			i -= c.N
+		default:
+			dst = append(dst, utf16.Encode([]rune(c.Insert))...)
+		}
+	}
+
+	return string(utf16.Decode(dst)), nil
+}
+
+// Transform takes two operations a and b that apply to the same text and
+// returns a' and b' so that applying a then b' gives the same text as applying
+// b then a'. When both insert at the same position the insert of a is first.
+func Transform(a Op, b Op) (Op, Op, error) {
+	if a.BaseLen() != b.BaseLen() {
+		return nil, nil, ErrLength
+	}
+
+	var a1, b1 Op
+	i, j := 0, 0
+
+	// Copy the components so the lengths can be reduced as they are used
+	var ca, cb *Component
+	next := func(o Op, k *int) *Component {
+		if *k >= len(o) {
+			return nil
+		}
+		c := o[*k]
+		*k++
+		return &c
+	}
+	ca, cb = next(a, &i), next(b, &j)
+
+	for ca != nil || cb != nil {
+		if ca != nil && len(ca.Insert) > 0 {
+			a1 = a1.Insert(ca.Insert)
+			b1 = b1.Retain(length(ca.Insert))
+			ca = next(a, &i)
+			continue
+		}
+		if cb != nil && len(cb.Insert) > 0 {
+			a1 = a1.Retain(length(cb.Insert))
+			b1 = b1.Insert(cb.Insert)
+			cb = next(b, &j)
+			continue
+		}
+		if ca == nil || cb == nil {
+			return nil, nil, ErrLength
+		}
+
+		na, nb := abs(ca.N), abs(cb.N)
+		n := na
+		if nb < n {
+			n = nb
+		}
+
+		switch {
+		case ca.N > 0 && cb.N > 0:
+			a1 = a1.Retain(n)
+			b1 = b1.Retain(n)
+		case ca.N < 0 && cb.N > 0:
+			a1 = a1.Delete(n)
+		case ca.N > 0 && cb.N < 0:
+			b1 = b1.Delete(n)
+		}
+		// Both deleted the same text so neither needs to delete it again
+
+		ca = shrink(ca, n, a, &i, next)
+		cb = shrink(cb, n, b, &j, next)
+	}
+
+	return a1, b1, nil
+}
+
+// shrink uses n characters of a retain or delete and returns the component
+// with the rest, or the next component when it is used up.
+func shrink(c *Component, n int, o Op, k *int, next func(Op, *int) *Component) *Component {
+	if abs(c.N) == n {
+		return next(o, k)
+	}
+	if c.N > 0 {
+		c.N -= n
+	} else {
+		c.N += n
+	}
+	return c
+}
+
+// abs returns the absolute value.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// MarshalJSON writes the operation as an array of numbers and strings.
+func (o Op) MarshalJSON() ([]byte, error) {
+	list := make([]interface{}, len(o))
+	for i, c := range o {
+		if len(c.Insert) > 0 {
+			list[i] = c.Insert
+		} else {
+			list[i] = c.N
+		}
+	}
+	return json.Marshal(list)
+}
+
+// UnmarshalJSON reads an array of numbers and strings.
+func (o *Op) UnmarshalJSON(b []byte) error {
+	var list []interface{}
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+
+	var op Op
+	for _, v := range list {
+		switch t := v.(type) {
+		case float64:
+			n := int(t)
+			if float64(n) != t || n == 0 {
+				return fmt.Errorf("component %v is not a valid length", t)
+			}
+			if n > 0 {
+				op = op.Retain(n)
+			} else {
+				op = op.Delete(-n)
+			}
+		case string:
+			if len(t) == 0 {
+				return errors.New("insert is empty")
+			}
+			op = op.Insert(t)
+		default:
+			return fmt.Errorf("component %v is not a number or a string", v)
+		}
+	}
+
+	*o = op
+	return nil
+}
@@ -0,0 +1,141 @@
+package ot_test
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/blue-jay/blueprint/lib/ot"
+)
+
+// TestApply ensures an operation changes the text.
+func TestApply(t *testing.T) {
+	op := ot.Op{}.Retain(6).Delete(5).Insert("there").Retain(1)
+
+	received, err := op.Apply("Hello world!")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Hello there!"
+	if received != expected {
+		t.Errorf("\n got: %v\nwant: %v", received, expected)
+	}
+
+	if _, err := op.Apply("Too short"); err != ot.ErrLength {
+		t.Errorf("got %v want %v", err, ot.ErrLength)
+	}
+}
+
+// TestUTF16 ensures lengths are counted like JavaScript strings.
+func TestUTF16(t *testing.T) {
+	// The emoji is two UTF-16 code units
+	op := ot.Op{}.Retain(2).Insert("!").Retain(1)
+
+	received, err := op.Apply("😀a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received != "😀!a" {
+		t.Errorf("\n got: %v\nwant: %v", received, "😀!a")
+	}
+}
+
+// TestJSON ensures operations are read and written as arrays.
+func TestJSON(t *testing.T) {
+	var op ot.Op
+	if err := json.Unmarshal([]byte(`[3,"abc",-2,1]`), &op); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != `[3,"abc",-2,1]` {
+		t.Errorf("\n got: %s\nwant: %s", b, `[3,"abc",-2,1]`)
+	}
+
+	for _, invalid := range []string{`[0]`, `[1.5]`, `[""]`, `[true]`, `{}`} {
+		if err := json.Unmarshal([]byte(invalid), &op); err == nil {
+			t.Errorf("%v should not be valid", invalid)
+		}
+	}
+}
+
+// TestTransform ensures two concurrent operations give the same text in
+// either order.
+func TestTransform(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		text := randomText(r, 20)
+		a := randomOp(r, text)
+		b := randomOp(r, text)
+
+		a1, b1, err := ot.Transform(a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ab, err := apply(text, a, b1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ba, err := apply(text, b, a1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ab != ba {
+			t.Fatalf("text %q a %v b %v\n a then b': %q\n b then a': %q", text, a, b, ab, ba)
+		}
+	}
+}
+
+// apply applies the operations in order.
+func apply(text string, ops ...ot.Op) (string, error) {
+	var err error
+	for _, op := range ops {
+		if text, err = op.Apply(text); err != nil {
+			return "", err
+		}
+	}
+	return text, nil
+}
+
+// randomText returns up to n random characters including one that is two
+// UTF-16 code units.
+func randomText(r *rand.Rand, n int) string {
+	chars := []rune("abc ✓😀")
+	s := make([]rune, r.Intn(n))
+	for i := range s {
+		s[i] = chars[r.Intn(len(chars))]
+	}
+	return string(s)
+}
+
+// randomOp returns a random operation on the text that never splits a
+// character.
+func randomOp(r *rand.Rand, text string) ot.Op {
+	var op ot.Op
+	for _, c := range text {
+		n := len(utf16.Encode([]rune{c}))
+		switch r.Intn(4) {
+		case 0:
+			op = op.Insert(randomText(r, 3)).Retain(n)
+		case 1:
+			op = op.Delete(n)
+		default:
+			op = op.Retain(n)
+		}
+	}
+	if r.Intn(2) == 0 {
+		op = op.Insert(randomText(r, 3))
+	}
+	return op
+}
@@ -0,0 +1,41 @@
+// Package placeholder replaces variables such as {{date}} in note templates.
+package placeholder
+
+import (
+	"regexp"
+	"time"
+)
+
+var (
+	// pattern matches a variable name between double braces.
+	pattern = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+)
+
+// Values returns the variables that can be used in a note template. Times are
+// in UTC like the rest of the application.
+func Values(now time.Time, firstName string) map[string]string {
+	now = now.UTC()
+	return map[string]string{
+		"date":       now.Format("2006-01-02"),
+		"time":       now.Format("15:04"),
+		"weekday":    now.Format("Monday"),
+		"first_name": firstName,
+	}
+}
+
+// Names returns the variable names in the order they should be listed.
+func Names() []string {
+	return []string{"date", "time", "weekday", "first_name"}
+}
+
+// Replace returns the text with each variable replaced by its value. Unknown
+// variables are left unchanged.
+func Replace(text string, values map[string]string) string {
+	return pattern.ReplaceAllStringFunc(text, func(m string) string {
+		name := pattern.FindStringSubmatch(m)[1]
+		if v, ok := values[name]; ok {
+			return v
+		}
+		return m
+	})
+}
@@ -0,0 +1,32 @@
+package placeholder_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/placeholder"
+)
+
+// TestReplace ensures known variables are replaced and unknown ones are kept.
+func TestReplace(t *testing.T) {
+	now := time.Date(2016, 6, 30, 2, 5, 0, 0, time.UTC)
+	values := placeholder.Values(now, "John")
+
+	text := "# Meeting {{date}} ({{ weekday }})\nOwner: {{first_name}} at {{time}}\n{{unknown}}"
+
+	expected := "# Meeting 2016-06-30 (Thursday)\nOwner: John at 02:05\n{{unknown}}"
+	received := placeholder.Replace(text, values)
+	if received != expected {
+		t.Errorf("\n got: %v\nwant: %v", received, expected)
+	}
+}
+
+// TestNames ensures every listed name has a value.
+func TestNames(t *testing.T) {
+	values := placeholder.Values(time.Now(), "")
+	for _, name := range placeholder.Names() {
+		if _, ok := values[name]; !ok {
+			t.Errorf("missing value for %v", name)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Package postgresql connects to a PostgreSQL database.
+package postgresql
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/blue-jay/core/storage/migration"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+// Driver is the name of the PostgreSQL driver.
+const Driver = "postgres"
+
+// Info holds the details for the PostgreSQL connection. The Parameter is added
+// to the connection URL, like sslmode=disable.
+type Info struct {
+	Username  string         `json:"Username"`
+	Password  string         `json:"Password"`
+	Database  string         `json:"Database"`
+	Hostname  string         `json:"Hostname"`
+	Port      int            `json:"Port"`
+	Parameter string         `json:"Parameter"`
+	Migration migration.Info `json:"Migration"`
+}
+
+// DSN returns the data source name. The postgres database is used when
+// specificDatabase is false so the database can be created.
+func (c Info) DSN(specificDatabase bool) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.Username, c.Password),
+		Host:     fmt.Sprintf("%v:%v", c.Hostname, c.Port),
+		Path:     "/postgres",
+		RawQuery: c.Parameter,
+	}
+	if specificDatabase {
+		u.Path = "/" + c.Database
+	}
+	return u.String()
+}
+
+// Connect to the database.
+func (c Info) Connect(specificDatabase bool) (*sqlx.DB, error) {
+	return sqlx.Connect(Driver, c.DSN(specificDatabase))
+}
@@ -0,0 +1,166 @@
+// Package pubsub delivers messages to the subscribers of a topic. Messages are
+// sent through a backend so a backend that is shared between application
+// instances delivers to the subscribers of every instance.
+package pubsub
+
+import (
+	"log"
+	"sync"
+)
+
+// bufferSize is the number of messages a subscriber can fall behind before
+// messages are dropped.
+const bufferSize = 16
+
+// Backend sends messages between hubs. A backend must call the handler once
+// for every message published to it, including the messages published by the
+// same hub.
+type Backend interface {
+	Publish(topic string, payload []byte) error
+	Listen(handler func(topic string, payload []byte)) error
+	Close() error
+}
+
+// Subscription receives the messages of a topic on C until it is closed.
+type Subscription struct {
+	C     <-chan []byte
+	c     chan []byte
+	topic string
+	hub   *Hub
+}
+
+// Close stops the subscription and closes C.
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+// Hub holds the subscribers of this application instance.
+type Hub struct {
+	backend Backend
+	topics  map[string]map[*Subscription]bool
+	mutex   sync.RWMutex
+}
+
+// New returns a hub that sends messages through the backend.
+func New(backend Backend) (*Hub, error) {
+	h := &Hub{
+		backend: backend,
+		topics:  make(map[string]map[*Subscription]bool),
+	}
+
+	if err := backend.Listen(h.deliver); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// Publish sends the payload to the subscribers of the topic.
+func (h *Hub) Publish(topic string, payload []byte) error {
+	return h.backend.Publish(topic, payload)
+}
+
+// Subscribe returns a subscription to the topic.
+func (h *Hub) Subscribe(topic string) *Subscription {
+	c := make(chan []byte, bufferSize)
+	s := &Subscription{C: c, c: c, topic: topic, hub: h}
+
+	h.mutex.Lock()
+	if h.topics[topic] == nil {
+		h.topics[topic] = make(map[*Subscription]bool)
+	}
+	h.topics[topic][s] = true
+	h.mutex.Unlock()
+
+	return s
+}
+
+// Close stops the backend and closes every subscription so the streams to
+// the clients end.
+func (h *Hub) Close() error {
+	err := h.backend.Close()
+
+	h.mutex.Lock()
+	for topic, subs := range h.topics {
+		for s := range subs {
+			close(s.c)
+		}
+		delete(h.topics, topic)
+	}
+	h.mutex.Unlock()
+
+	return err
+}
+
+// remove stops a subscription.
+func (h *Hub) remove(s *Subscription) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if !h.topics[s.topic][s] {
+		return
+	}
+
+	delete(h.topics[s.topic], s)
+	if len(h.topics[s.topic]) == 0 {
+		delete(h.topics, s.topic)
+	}
+	close(s.c)
+}
+
+// deliver sends a message from the backend to the subscribers of the topic.
+// The message is dropped for a subscriber that is too far behind so a slow
+// client does not block the others.
+func (h *Hub) deliver(topic string, payload []byte) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	for s := range h.topics[topic] {
+		select {
+		case s.c <- payload:
+		default:
+			log.Println("pubsub: dropped message for slow subscriber on", topic)
+		}
+	}
+}
+
+// *****************************************************************************
+// Memory Backend
+// *****************************************************************************
+
+// Memory is a backend that only delivers messages inside this application
+// instance.
+type Memory struct {
+	handler func(topic string, payload []byte)
+	mutex   sync.RWMutex
+}
+
+// NewMemory returns a backend for a single application instance.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Publish delivers the message to the hub.
+func (m *Memory) Publish(topic string, payload []byte) error {
+	m.mutex.RLock()
+	handler := m.handler
+	m.mutex.RUnlock()
+
+	if handler != nil {
+		handler(topic, payload)
+	}
+	return nil
+}
+
+// Listen sets the handler of the hub.
+func (m *Memory) Listen(handler func(topic string, payload []byte)) error {
+	m.mutex.Lock()
+	m.handler = handler
+	m.mutex.Unlock()
+	return nil
+}
+
+// Close stops delivering messages.
+func (m *Memory) Close() error {
+	return m.Listen(nil)
+}
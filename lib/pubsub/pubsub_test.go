@@ -0,0 +1,84 @@
+package pubsub_test
+
+import (
+	"testing"
+
+	"github.com/blue-jay/blueprint/lib/pubsub"
+)
+
+// TestPublish ensures only the subscribers of a topic get a message.
+func TestPublish(t *testing.T) {
+	h, err := pubsub.New(pubsub.NewMemory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := h.Subscribe("a")
+	b := h.Subscribe("b")
+	defer b.Close()
+
+	if err := h.Publish("a", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-a.C:
+		if string(msg) != "hello" {
+			t.Errorf("\n got: %v\nwant: %v", string(msg), "hello")
+		}
+	default:
+		t.Error("subscriber did not get the message")
+	}
+
+	select {
+	case msg := <-b.C:
+		t.Errorf("subscriber of another topic got the message: %v", string(msg))
+	default:
+	}
+
+	// A closed subscription no longer gets messages
+	a.Close()
+	if _, ok := <-a.C; ok {
+		t.Error("channel should be closed")
+	}
+	if err := h.Publish("a", []byte("again")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSlowSubscriber ensures a full subscriber does not block the publisher.
+func TestSlowSubscriber(t *testing.T) {
+	h, err := pubsub.New(pubsub.NewMemory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := h.Subscribe("a")
+	defer s.Close()
+
+	for i := 0; i < 100; i++ {
+		if err := h.Publish("a", []byte("message")); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestHubClose ensures closing the hub closes every subscription.
+func TestHubClose(t *testing.T) {
+	h, err := pubsub.New(pubsub.NewMemory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := h.Subscribe("a")
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := <-s.C; ok {
+		t.Error("channel should be closed")
+	}
+
+	// Closing the subscription again does nothing
+	s.Close()
+}
@@ -0,0 +1,192 @@
+// Package reminder queues email reminders for notes that are due. The emails
+// are sent by the job queue.
+package reminder
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/jobs"
+	"github.com/blue-jay/blueprint/lib/task"
+	"github.com/blue-jay/blueprint/model/note"
+)
+
+// errClaimLost means another scheduler took over the claim of a reminder.
+var errClaimLost = errors.New("the claim was taken by another scheduler")
+
+// Info holds the details for the reminder scheduler.
+type Info struct {
+	Enabled bool `json:"Enabled"`
+	// Interval is the number of seconds between checks for due reminders.
+	Interval int `json:"Interval"`
+	// ClaimTimeout is the number of seconds before a reminder claimed by a
+	// scheduler that stopped is sent by another scheduler.
+	ClaimTimeout int `json:"ClaimTimeout"`
+	// BatchSize is the maximum number of reminders queued per check.
+	BatchSize int `json:"BatchSize"`
+}
+
+// Scheduler checks for due reminders on an interval and queues a job to send
+// each one. Each reminder is claimed in the database before it is queued so
+// multiple application instances do not queue the same reminder.
+type Scheduler struct {
+	db    note.TxConnection
+	info  Info
+	stop  chan struct{}
+	done  chan struct{}
+	mutex sync.Mutex
+}
+
+// New returns a scheduler that is not started.
+func New(db note.TxConnection, info Info) *Scheduler {
+	if info.Interval <= 0 {
+		info.Interval = 60
+	}
+	if info.ClaimTimeout <= 0 {
+		info.ClaimTimeout = 300
+	}
+	if info.BatchSize <= 0 {
+		info.BatchSize = 50
+	}
+
+	return &Scheduler{
+		db:   db,
+		info: info,
+	}
+}
+
+// Start checks for due reminders in the background until Stop is called.
+func (s *Scheduler) Start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go s.loop(s.stop, s.done)
+}
+
+// Stop stops the background checks and waits for the current check to finish.
+func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stop == nil {
+		return
+	}
+
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+	s.done = nil
+}
+
+// loop runs a check on every interval.
+func (s *Scheduler) loop(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(time.Duration(s.info.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunOnce(time.Now()); err != nil {
+			log.Println("reminder:", err)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce queues the reminders that are due at the time now and returns the
+// number queued. A reminder that cannot be queued is released so it is retried
+// on the next check.
+func (s *Scheduler) RunOnce(now time.Time) (int, error) {
+	staleBefore := now.Add(-time.Duration(s.info.ClaimTimeout) * time.Second)
+
+	items, err := note.DueReminders(s.db, now, staleBefore, s.info.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, item := range items {
+		claim, err := newClaim()
+		if err != nil {
+			return queued, err
+		}
+
+		ok, err := note.ClaimReminder(s.db, item.ID, claim, now, staleBefore)
+		if err != nil {
+			return queued, err
+		} else if !ok {
+			// Another scheduler claimed it first
+			continue
+		}
+
+		subject, body := Message(item)
+		err = note.Transaction(s.db, func(tx note.Connection) error {
+			result, err := note.MarkReminderSent(tx, item.ID, claim, now)
+			if err != nil {
+				return err
+			}
+
+			// Do not queue the email when another scheduler took the claim
+			if rows, err := result.RowsAffected(); err != nil {
+				return err
+			} else if rows != 1 {
+				return errClaimLost
+			}
+
+			return jobs.Enqueue(tx, task.SendEmail, task.Email{To: item.Email, Subject: subject, Body: body})
+		})
+		if err != nil {
+			log.Printf("reminder: could not queue reminder for note %v: %v\n", item.ID, err)
+			if _, err := note.ReleaseReminder(s.db, item.ID, claim); err != nil {
+				log.Println("reminder:", err)
+			}
+			continue
+		}
+		queued++
+	}
+
+	return queued, nil
+}
+
+// Message returns the subject and body of the email for a reminder.
+func Message(r note.Reminder) (string, string) {
+	name := r.Name
+	if runes := []rune(name); len(runes) > 50 {
+		name = string(runes[:50]) + "..."
+	}
+
+	subject := fmt.Sprintf("Reminder: %v", name)
+	body := fmt.Sprintf("Hi %v,\n\nThis is a reminder about your note", r.FirstName)
+	if r.DueAt.Valid {
+		body += fmt.Sprintf(" due %v UTC", r.DueAt.Time.UTC().Format("Jan _2, 2006 3:04 PM"))
+	}
+	body += fmt.Sprintf(":\n\n%v\n", r.Name)
+
+	return subject, body
+}
+
+// newClaim returns a random value that identifies a single send attempt.
+func newClaim() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,37 @@
+package reminder_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/blue-jay/blueprint/lib/reminder"
+	"github.com/blue-jay/blueprint/model/note"
+)
+
+// TestMessage ensures a long name is shortened in the subject without
+// splitting a character.
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Call the bank", "Reminder: Call the bank"},
+		{strings.Repeat("a", 60), "Reminder: " + strings.Repeat("a", 50) + "..."},
+		{strings.Repeat("é", 60), "Reminder: " + strings.Repeat("é", 50) + "..."},
+		{"a" + strings.Repeat("日", 60), "Reminder: a" + strings.Repeat("日", 49) + "..."},
+	}
+
+	for _, tt := range tests {
+		subject, body := reminder.Message(note.Reminder{Name: tt.name, FirstName: "John"})
+		if subject != tt.expected {
+			t.Errorf("got subject %q, want %q", subject, tt.expected)
+		}
+		if !utf8.ValidString(subject) {
+			t.Errorf("subject %q is not valid UTF-8", subject)
+		}
+		if !strings.Contains(body, tt.name) {
+			t.Errorf("body %q does not have the full name", body)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Package respond writes the JSON responses of the API.
+package respond
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/blue-jay/blueprint/middleware/requestid"
+)
+
+var (
+	// prefix is the start of the path of every API request.
+	prefix = "/api/"
+)
+
+// ErrorInfo is the body of every error response.
+type ErrorInfo struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an error. Fields holds a message for each invalid
+// field in the request body.
+type ErrorDetail struct {
+	Status  int               `json:"status"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// IsAPI returns true if the request is for the API.
+func IsAPI(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, prefix)
+}
+
+// JSON writes the value as JSON with the status code.
+func JSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println(err)
+	}
+}
+
+// Error writes an error with the status code.
+func Error(w http.ResponseWriter, status int, message string) {
+	JSON(w, status, ErrorInfo{ErrorDetail{
+		Status:  status,
+		Message: message,
+	}})
+}
+
+// ErrorGeneric logs the error and writes a generic error so the details are
+// not shown to the client. The request ID from the response header is logged
+// and included in the message so the client can quote it.
+func ErrorGeneric(w http.ResponseWriter, err error) {
+	id := w.Header().Get(requestid.Header)
+	log.Output(2, fmt.Sprintf("request_id=%v %v", id, err))
+
+	message := "An error occurred on the server. Please try again later."
+	if len(id) > 0 {
+		message += " Request ID: " + id
+	}
+	Error(w, http.StatusInternalServerError, message)
+}
+
+// Invalid writes the validation errors for the fields of the request body.
+func Invalid(w http.ResponseWriter, fields map[string]string) {
+	JSON(w, http.StatusUnprocessableEntity, ErrorInfo{ErrorDetail{
+		Status:  http.StatusUnprocessableEntity,
+		Message: "Request body is not valid.",
+		Fields:  fields,
+	}})
+}
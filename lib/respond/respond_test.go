@@ -0,0 +1,70 @@
+package respond_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blue-jay/blueprint/lib/respond"
+	"github.com/blue-jay/blueprint/middleware/requestid"
+)
+
+// TestInvalid ensures validation errors have the status and the fields.
+func TestInvalid(t *testing.T) {
+	w := httptest.NewRecorder()
+	respond.Invalid(w, map[string]string{"name": "Name is required."})
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("\n got: %v\nwant: %v", w.Code, http.StatusUnprocessableEntity)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("wrong content type: %v", ct)
+	}
+
+	var body respond.ErrorInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Error.Status != http.StatusUnprocessableEntity || body.Error.Fields["name"] != "Name is required." {
+		t.Errorf("wrong body: %v", w.Body.String())
+	}
+}
+
+// TestIsAPI ensures only API paths are detected.
+func TestIsAPI(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"/api/v1/notes": true,
+		"/notepad":      false,
+		"/apiary":       false,
+	} {
+		r := httptest.NewRequest("GET", path, nil)
+		if received := respond.IsAPI(r); received != expected {
+			t.Errorf("%v\n got: %v\nwant: %v", path, received, expected)
+		}
+	}
+}
+
+// TestErrorGeneric ensures the request ID is shown instead of the error.
+func TestErrorGeneric(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set(requestid.Header, "abc123")
+	respond.ErrorGeneric(w, errors.New("database is down"))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("\n got: %v\nwant: %v", w.Code, http.StatusInternalServerError)
+	}
+
+	var body respond.ErrorInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(body.Error.Message, "abc123") || strings.Contains(body.Error.Message, "database") {
+		t.Errorf("wrong message: %v", body.Error.Message)
+	}
+}
@@ -0,0 +1,144 @@
+// Package route registers the routes of the controllers with the router and
+// records the pattern of the route that matched each request for the metrics.
+package route
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/blue-jay/blueprint/lib/metrics"
+
+	"github.com/husobee/vestigo"
+	"github.com/justinas/alice"
+)
+
+// Router holds the routes of one application. Each application has its own
+// so more than one can run in a process.
+type Router struct {
+	mux              *vestigo.Router
+	notFound         http.HandlerFunc
+	methodNotAllowed vestigo.MethodNotAllowedHandlerFunc
+	mutex            sync.RWMutex
+}
+
+// routerKey is the context key of the router that is serving a request.
+type routerKey struct{}
+
+// The handlers vestigo calls when no route matches are set once for the
+// process, so they pass the request to the router that is serving it.
+func init() {
+	vestigo.CustomNotFoundHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromContext(r).handleNotFound(w, r)
+	})
+	vestigo.CustomMethodNotAllowedHandlerFunc(func(allowedMethods string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fromContext(r).handleMethodNotAllowed(allowedMethods)(w, r)
+		}
+	})
+}
+
+// New returns a router without routes.
+func New() *Router {
+	return &Router{
+		mux: vestigo.NewRouter(),
+	}
+}
+
+// fromContext returns the router that is serving the request. It returns an
+// empty router when the request is not served by a Router so the defaults are
+// used.
+func fromContext(r *http.Request) *Router {
+	if rt, ok := r.Context().Value(routerKey{}).(*Router); ok {
+		return rt
+	}
+	return &Router{}
+}
+
+// ServeHTTP passes the request to the route that matches it.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.mux.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routerKey{}, rt)))
+}
+
+// NotFound sets the handler for the requests that do not match a route.
+func (rt *Router) NotFound(fn http.HandlerFunc) {
+	rt.mutex.Lock()
+	rt.notFound = fn
+	rt.mutex.Unlock()
+}
+
+// MethodNotAllowed sets the handler for the requests that match a route with
+// another method.
+func (rt *Router) MethodNotAllowed(fn vestigo.MethodNotAllowedHandlerFunc) {
+	rt.mutex.Lock()
+	rt.methodNotAllowed = fn
+	rt.mutex.Unlock()
+}
+
+// handleNotFound serves a request that did not match a route.
+func (rt *Router) handleNotFound(w http.ResponseWriter, r *http.Request) {
+	rt.mutex.RLock()
+	fn := rt.notFound
+	rt.mutex.RUnlock()
+
+	if fn == nil {
+		fn = http.NotFound
+	}
+	fn(w, r)
+}
+
+// handleMethodNotAllowed returns the handler for a request that matched a
+// route with another method.
+func (rt *Router) handleMethodNotAllowed(allowedMethods string) http.HandlerFunc {
+	rt.mutex.RLock()
+	fn := rt.methodNotAllowed
+	rt.mutex.RUnlock()
+
+	if fn == nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", allowedMethods)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	}
+	return fn(allowedMethods)
+}
+
+// Add registers a route for the method.
+func (rt *Router) Add(method string, path string, fn http.HandlerFunc, c ...alice.Constructor) {
+	rt.mux.Add(method, path, handler(path, fn, c...))
+}
+
+// Get registers a route for the GET method.
+func (rt *Router) Get(path string, fn http.HandlerFunc, c ...alice.Constructor) {
+	rt.Add(http.MethodGet, path, fn, c...)
+}
+
+// Post registers a route for the POST method.
+func (rt *Router) Post(path string, fn http.HandlerFunc, c ...alice.Constructor) {
+	rt.Add(http.MethodPost, path, fn, c...)
+}
+
+// Put registers a route for the PUT method.
+func (rt *Router) Put(path string, fn http.HandlerFunc, c ...alice.Constructor) {
+	rt.Add(http.MethodPut, path, fn, c...)
+}
+
+// Patch registers a route for the PATCH method.
+func (rt *Router) Patch(path string, fn http.HandlerFunc, c ...alice.Constructor) {
+	rt.Add(http.MethodPatch, path, fn, c...)
+}
+
+// Delete registers a route for the DELETE method.
+func (rt *Router) Delete(path string, fn http.HandlerFunc, c ...alice.Constructor) {
+	rt.Add(http.MethodDelete, path, fn, c...)
+}
+
+// handler returns the handler with its middleware that records the pattern
+// first so the requests stopped by the middleware are labeled by the route.
+func handler(path string, fn http.HandlerFunc, c ...alice.Constructor) http.HandlerFunc {
+	h := alice.New(c...).ThenFunc(fn)
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics.SetRoute(r, path)
+		h.ServeHTTP(w, r)
+	}
+}
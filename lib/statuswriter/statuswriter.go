@@ -0,0 +1,95 @@
+// Package statuswriter provides an http.ResponseWriter that records the status
+// code and the size of the response for the middleware. It passes flushing
+// and hijacking through to the original writer so streamed responses and
+// WebSockets work behind it.
+package statuswriter
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ErrHijack is returned when the original writer does not support hijacking.
+var ErrHijack = errors.New("statuswriter: the response writer does not support hijacking")
+
+// Writer records the status code and the number of bytes written.
+type Writer struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// New returns a writer that records the response sent to w. When w is
+// already a Writer it is returned so the middleware share one per request.
+func New(w http.ResponseWriter) *Writer {
+	if sw, ok := w.(*Writer); ok {
+		return sw
+	}
+	return &Writer{ResponseWriter: w}
+}
+
+// Status returns the status code sent to the client. It is 200 when the
+// response was not started.
+func (w *Writer) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Written returns true if the response was started.
+func (w *Writer) Written() bool {
+	return w.status != 0
+}
+
+// Bytes returns the number of bytes written in the body.
+func (w *Writer) Bytes() int64 {
+	return w.bytes
+}
+
+// WriteHeader records the status code.
+func (w *Writer) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes written.
+func (w *Writer) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends the buffered data to the client for streamed responses.
+func (w *Writer) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection for WebSockets.
+func (w *Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, ErrHijack
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the original response writer.
+func (w *Writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,71 @@
+package statuswriter_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blue-jay/blueprint/lib/statuswriter"
+)
+
+// TestWriter ensures the first status and the size of the body are recorded.
+func TestWriter(t *testing.T) {
+	w := statuswriter.New(httptest.NewRecorder())
+	if w.Written() || w.Status() != http.StatusOK {
+		t.Fatalf("got written %v status %v before the response", w.Written(), w.Status())
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte("not found"))
+
+	if !w.Written() {
+		t.Error("response was not recorded as written")
+	}
+	if w.Status() != http.StatusNotFound {
+		t.Errorf("got status %v, want %v", w.Status(), http.StatusNotFound)
+	}
+	if w.Bytes() != 9 {
+		t.Errorf("got %v bytes, want 9", w.Bytes())
+	}
+}
+
+// TestFlush ensures flushing starts the response and reaches the original
+// writer.
+func TestFlush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := statuswriter.New(rec)
+
+	var f http.Flusher = w
+	f.Flush()
+
+	if !rec.Flushed {
+		t.Error("original writer was not flushed")
+	}
+	if !w.Written() || w.Status() != http.StatusOK {
+		t.Errorf("got written %v status %v after a flush", w.Written(), w.Status())
+	}
+}
+
+// TestHijack ensures a writer that cannot be hijacked returns an error.
+func TestHijack(t *testing.T) {
+	w := statuswriter.New(httptest.NewRecorder())
+
+	if _, _, err := w.Hijack(); err != statuswriter.ErrHijack {
+		t.Errorf("got %v, want %v", err, statuswriter.ErrHijack)
+	}
+	if w.Written() {
+		t.Error("failed hijack was recorded as written")
+	}
+	if w.Unwrap() == nil {
+		t.Error("original writer is missing")
+	}
+}
+
+// TestNew ensures a writer is reused instead of wrapped again.
+func TestNew(t *testing.T) {
+	w := statuswriter.New(httptest.NewRecorder())
+	if statuswriter.New(w) != w {
+		t.Error("writer was wrapped again")
+	}
+}
@@ -0,0 +1,55 @@
+// Package task defines the background jobs of the application and registers
+// their handlers with the job queue.
+package task
+
+import (
+	"context"
+	"log"
+
+	"github.com/blue-jay/blueprint/lib/jobs"
+	"github.com/blue-jay/blueprint/model/note"
+)
+
+const (
+	// SendEmail is the type of job that sends an email. The reminders are
+	// sent with it.
+	SendEmail = "email.send"
+	// PurgeTrash is the type of job that removes the notes in the trash of a
+	// user.
+	PurgeTrash = "trash.purge"
+)
+
+// Email is the payload of a SendEmail job.
+type Email struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Trash is the payload of a PurgeTrash job.
+type Trash struct {
+	UserID string `json:"user_id"`
+}
+
+// Sender sends an email.
+type Sender interface {
+	Send(to, subject, body string) error
+}
+
+// Register sets the handlers for the jobs of the application.
+func Register(q *jobs.Queue, db note.Connection, sender Sender) {
+	q.Register(SendEmail, func(ctx context.Context, e Email) error {
+		return sender.Send(e.To, e.Subject, e.Body)
+	})
+
+	q.Register(PurgeTrash, func(ctx context.Context, t Trash) error {
+		result, err := note.PurgeDeleted(db, t.UserID)
+		if err != nil {
+			return err
+		}
+
+		rows, _ := result.RowsAffected()
+		log.Printf("task: removed %v item(s) from the trash of user %v\n", rows, t.UserID)
+		return nil
+	})
+}
@@ -1,11 +1,13 @@
 // Package acl provides http.Handlers to prevent access to pages for
-// authenticated users and for non-authenticated users.
+// authenticated users, for non-authenticated users, and for users who are not
+// admins.
 package acl
 
 import (
 	"net/http"
 
 	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/model/userrole"
 )
 
 // DisallowAuth does not allow authenticated users to access the page.
@@ -37,3 +39,18 @@ func DisallowAnon(h http.Handler) http.Handler {
 		h.ServeHTTP(w, r)
 	})
 }
+
+// AllowAdmin only allows users with the admin role to access the page.
+func AllowAdmin(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := flight.Context(w, r)
+
+		// If user is not an admin, don't allow them to access the page
+		if c.Sess.Values["id"] == nil || c.Sess.Values["role_id"] != userrole.Admin {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,29 @@
+// Package instrument provides an http.Handler that records the count and the
+// latency of each request to the application in the metrics.
+package instrument
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/metrics"
+	"github.com/blue-jay/blueprint/lib/statuswriter"
+)
+
+// New returns a handler that records the requests in the metrics by route
+// pattern, method, and status. The routes record their pattern in the context
+// of the request when they are served and the requests that did not match a
+// route are Unmatched.
+func New(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := statuswriter.New(w)
+			ctx := metrics.WithRoute(r.Context())
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			route, method := metrics.RouteOf(ctx, r.Method)
+			m.Observe(route, method, rw.Status(), time.Since(start))
+		})
+	}
+}
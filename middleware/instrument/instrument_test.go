@@ -0,0 +1,54 @@
+package instrument_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blue-jay/blueprint/lib/metrics"
+	"github.com/blue-jay/blueprint/middleware/instrument"
+	"github.com/blue-jay/blueprint/middleware/rest"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestHandler ensures the requests are labeled by the recorded pattern and
+// the parameters in the query string cannot add a route.
+func TestHandler(t *testing.T) {
+	m := metrics.New()
+	matched := instrument.New(m)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metrics.SetRoute(r, "/instrument/:id")
+	}))
+	unmatched := instrument.New(m)(http.NotFoundHandler())
+
+	matched.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/instrument/1", nil))
+	unmatched.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/instrument/2?:_name=2", nil))
+
+	if got := testutil.ToFloat64(m.Requests.WithLabelValues("/instrument/:id", "GET", "200")); got != 1 {
+		t.Errorf("matched got %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.Requests.WithLabelValues(metrics.Unmatched, "GET", "404")); got != 1 {
+		t.Errorf("unmatched got %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.Requests.WithLabelValues("/instrument/*", "GET", "404")); got != 0 {
+		t.Errorf("query route got %v, want 0", got)
+	}
+}
+
+// TestMethodOverride ensures a form that changes the method is counted with
+// the method of the route it matched.
+func TestMethodOverride(t *testing.T) {
+	m := metrics.New()
+	h := instrument.New(m)(rest.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metrics.SetRoute(r, "/override/:id")
+	})))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/override/1?_method=delete", nil))
+
+	if got := testutil.ToFloat64(m.Requests.WithLabelValues("/override/:id", "DELETE", "200")); got != 1 {
+		t.Errorf("DELETE got %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.Requests.WithLabelValues("/override/:id", "POST", "200")); got != 0 {
+		t.Errorf("POST got %v, want 0", got)
+	}
+}
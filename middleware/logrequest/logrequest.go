@@ -1,18 +1,57 @@
-// Package logrequest provides an http.Handler that logs when a request is
-// made to the application and lists the remote address, the HTTP method,
-// and the URL.
+// Package logrequest provides an http.Handler that writes an access log line
+// for each request to the application with the status code, the response
+// size, the latency, the user, and the request ID.
 package logrequest
 
 import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/blue-jay/blueprint/lib/accesslog"
+	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/lib/statuswriter"
+	"github.com/blue-jay/blueprint/middleware/requestid"
 )
 
-// Handler will log the HTTP requests.
-func Handler(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		fmt.Println(time.Now().Format("2006-01-02 03:04:05 PM"), r.RemoteAddr, r.Method, r.URL)
-		next.ServeHTTP(w, r)
-	})
+// New returns a handler that logs the HTTP requests to the logger.
+func New(l *accesslog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if l.Skip(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rw := statuswriter.New(w)
+			next.ServeHTTP(rw, r)
+
+			if !l.Sampled(r.URL.Path, rw.Status()) {
+				return
+			}
+
+			l.Log(accesslog.Entry{
+				Time:       start,
+				Method:     r.Method,
+				Path:       r.URL.RequestURI(),
+				Status:     rw.Status(),
+				Bytes:      rw.Bytes(),
+				Latency:    time.Since(start),
+				RemoteAddr: r.RemoteAddr,
+				UserID:     userID(w, r),
+				RequestID:  requestid.FromContext(r.Context()),
+				UserAgent:  r.UserAgent(),
+			})
+		})
+	}
+}
+
+// userID returns the ID of the user in the session or an empty string.
+func userID(w http.ResponseWriter, r *http.Request) string {
+	c := flight.Context(w, r)
+	if c.Sess == nil || c.Sess.Values["id"] == nil {
+		return ""
+	}
+	return fmt.Sprint(c.Sess.Values["id"])
 }
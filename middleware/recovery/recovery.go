@@ -0,0 +1,93 @@
+// Package recovery provides an http.Handler that turns a panic in a handler
+// into an error page. The stack is logged with the request ID and the panic is
+// sent to the reporters so it can be tracked by an error reporting service.
+package recovery
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/statuswriter"
+	"github.com/blue-jay/blueprint/middleware/requestid"
+)
+
+// Panic is a panic that was recovered.
+type Panic struct {
+	Value     interface{}
+	Stack     []byte
+	RequestID string
+	Request   *http.Request
+	Time      time.Time
+}
+
+// New returns a handler that recovers from a panic, sends it to the reporters,
+// and calls the error page. The reporters are called before the error page is
+// written so they should not block for long. Nothing is written when the
+// response was already started.
+func New(errorPage http.HandlerFunc, reporters ...func(Panic)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := statuswriter.New(w)
+
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+
+				// Let net/http close the connection without a log line
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				p := Panic{
+					Value:     v,
+					Stack:     debug.Stack(),
+					RequestID: requestid.FromContext(r.Context()),
+					Request:   r,
+					Time:      time.Now(),
+				}
+				log.Printf("request_id=%v panic: %v\n%s", p.RequestID, p.Value, p.Stack)
+				report(reporters, p)
+
+				if !rw.Written() {
+					render(errorPage, rw, r)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// report sends the panic to every reporter. A reporter that panics does not
+// stop the others.
+func report(reporters []func(Panic), p Panic) {
+	for _, fn := range reporters {
+		func() {
+			defer func() {
+				if v := recover(); v != nil {
+					log.Println("recovery: reporter panic:", v)
+				}
+			}()
+			fn(p)
+		}()
+	}
+}
+
+// render calls the error page and falls back to a plain response when the
+// error page panics too.
+func render(errorPage http.HandlerFunc, w *statuswriter.Writer, r *http.Request) {
+	defer func() {
+		if v := recover(); v != nil {
+			log.Println("recovery: error page panic:", v)
+			if !w.Written() {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}
+	}()
+
+	errorPage(w, r)
+}
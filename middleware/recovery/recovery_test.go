@@ -0,0 +1,69 @@
+package recovery_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blue-jay/blueprint/middleware/recovery"
+	"github.com/blue-jay/blueprint/middleware/requestid"
+)
+
+// errorPage writes the status like status.Error500.
+func errorPage(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "error page", http.StatusInternalServerError)
+}
+
+// TestPanic ensures a panic is reported and the error page is written.
+func TestPanic(t *testing.T) {
+	var got recovery.Panic
+	record := func(p recovery.Panic) { got = p }
+	fail := func(p recovery.Panic) { panic("reporter") }
+
+	h := requestid.Handler(recovery.New(errorPage, fail, record)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/notepad", nil)
+	r.Header.Set(requestid.Header, "abc")
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "error page") {
+		t.Errorf("got %v %q, want the error page", w.Code, w.Body.String())
+	}
+	if got.Value != "boom" || got.RequestID != "abc" || len(got.Stack) == 0 {
+		t.Errorf("got %+v, want the reported panic", got)
+	}
+}
+
+// TestPanicAfterWrite ensures the error page is not written after the
+// response was started.
+func TestPanicAfterWrite(t *testing.T) {
+	h := recovery.New(errorPage)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("got %v %q, want the partial response", w.Code, w.Body.String())
+	}
+}
+
+// TestAbortHandler ensures http.ErrAbortHandler is passed to net/http.
+func TestAbortHandler(t *testing.T) {
+	h := recovery.New(errorPage)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("got %v, want http.ErrAbortHandler", v)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
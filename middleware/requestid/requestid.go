@@ -0,0 +1,75 @@
+// Package requestid provides an http.Handler that gives every request an ID
+// so the log lines of a request can be found. The ID is taken from the
+// X-Request-ID header of the request when it is valid or is generated. It is
+// stored in the request context and sent back in the X-Request-ID header of
+// the response.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	// Header is the header that holds the request ID.
+	Header = "X-Request-ID"
+
+	// maxLength is the longest ID accepted from a client.
+	maxLength = 64
+)
+
+// key is the type of the context key so it does not collide with other keys.
+type key struct{}
+
+// Handler sets the request ID.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !Valid(id) {
+			id = New()
+		}
+
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// New returns a random ID.
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// Valid returns true if the ID from a client is not empty, not too long, and
+// only has letters, digits, dashes, underscores, periods, and colons so it is
+// safe to write to the logs.
+func Valid(id string) bool {
+	if len(id) == 0 || len(id) > maxLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns a copy of the context with the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// FromContext returns the request ID from the context or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
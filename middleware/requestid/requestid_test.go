@@ -0,0 +1,72 @@
+package requestid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blue-jay/blueprint/middleware/requestid"
+)
+
+// TestValid ensures only short IDs that are safe to log are accepted.
+func TestValid(t *testing.T) {
+	for id, expected := range map[string]bool{
+		"":                         false,
+		"abc-123_DEF.4:5":          true,
+		strings.Repeat("a", 64):    true,
+		strings.Repeat("a", 65):    false,
+		"has space":                false,
+		"new\nline":                false,
+		"quote\"":                  false,
+		"café":                     false,
+		"0123456789abcdef01234567": true,
+	} {
+		if received := requestid.Valid(id); received != expected {
+			t.Errorf("%q: got %v, want %v", id, received, expected)
+		}
+	}
+}
+
+// serve sends a request with the header through the handler and returns the
+// response and the ID in the context of the request.
+func serve(header string) (*httptest.ResponseRecorder, string) {
+	var id string
+	h := requestid.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = requestid.FromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	if len(header) > 0 {
+		r.Header.Set(requestid.Header, header)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w, id
+}
+
+// TestHandler ensures a valid ID from the client is kept, any other ID is
+// replaced, and the ID is in the response and the context.
+func TestHandler(t *testing.T) {
+	w, id := serve("client-id.1")
+	if id != "client-id.1" {
+		t.Errorf("got ID %q in the context, want client-id.1", id)
+	}
+	if received := w.Header().Get(requestid.Header); received != "client-id.1" {
+		t.Errorf("got ID %q in the response, want client-id.1", received)
+	}
+
+	for _, header := range []string{"", "bad id\r\n", strings.Repeat("a", 65)} {
+		w, id := serve(header)
+		if id == header || !requestid.Valid(id) {
+			t.Errorf("%q: got ID %q in the context, want a new ID", header, id)
+		}
+		if received := w.Header().Get(requestid.Header); received != id {
+			t.Errorf("%q: got ID %q in the response, want %q", header, received, id)
+		}
+	}
+
+	if _, other := serve(""); other == id {
+		t.Error("two requests got the same ID")
+	}
+}
@@ -0,0 +1,18 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 0;
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE note
+    DROP FOREIGN KEY `f_note_notebook`,
+    DROP COLUMN notebook_id;
+
+# ******************************************************************************
+# Remove tables
+# ******************************************************************************
+DROP TABLE IF EXISTS note_tag;
+DROP TABLE IF EXISTS tag;
+DROP TABLE IF EXISTS notebook;
\ No newline at end of file
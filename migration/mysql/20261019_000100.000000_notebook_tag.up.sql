@@ -0,0 +1,61 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 1;
+SET time_zone = '+00:00';
+
+# ******************************************************************************
+# Create tables
+# ******************************************************************************
+CREATE TABLE notebook (
+    id INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+    
+    name VARCHAR(100) NOT NULL,
+    
+    user_id INT(10) UNSIGNED NOT NULL,
+    
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    
+    UNIQUE KEY (user_id, name),
+    CONSTRAINT `f_notebook_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE tag (
+    id INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+    
+    name VARCHAR(50) NOT NULL,
+    
+    user_id INT(10) UNSIGNED NOT NULL,
+    
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    
+    UNIQUE KEY (user_id, name),
+    CONSTRAINT `f_tag_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE note_tag (
+    note_id INT(10) UNSIGNED NOT NULL,
+    tag_id INT(10) UNSIGNED NOT NULL,
+    
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    
+    CONSTRAINT `f_note_tag_note` FOREIGN KEY (`note_id`) REFERENCES `note` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT `f_note_tag_tag` FOREIGN KEY (`tag_id`) REFERENCES `tag` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (note_id, tag_id)
+);
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE note
+    ADD COLUMN notebook_id INT(10) UNSIGNED NULL DEFAULT NULL AFTER user_id,
+    ADD CONSTRAINT `f_note_notebook` FOREIGN KEY (`notebook_id`) REFERENCES `notebook` (`id`) ON DELETE SET NULL ON UPDATE CASCADE;
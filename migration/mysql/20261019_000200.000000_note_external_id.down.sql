@@ -0,0 +1,11 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 0;
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE note
+    DROP INDEX `u_note_external`,
+    DROP COLUMN external_id;
\ No newline at end of file
@@ -0,0 +1,17 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 1;
+SET time_zone = '+00:00';
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE note
+    ADD COLUMN external_id VARCHAR(100) NULL DEFAULT NULL AFTER notebook_id;
+
+UPDATE note SET external_id = REPLACE(UUID(), '-', '') WHERE external_id IS NULL;
+
+ALTER TABLE note
+    MODIFY COLUMN external_id VARCHAR(100) NOT NULL,
+    ADD UNIQUE KEY `u_note_external` (`user_id`, `external_id`);
\ No newline at end of file
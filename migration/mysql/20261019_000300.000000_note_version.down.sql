@@ -0,0 +1,10 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 0;
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE note
+    DROP COLUMN version;
@@ -0,0 +1,11 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 1;
+SET time_zone = '+00:00';
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE note
+    ADD COLUMN version INT(10) UNSIGNED NOT NULL DEFAULT 1 AFTER external_id;
@@ -0,0 +1,12 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 0;
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE note
+    DROP INDEX `i_note_user_pinned`,
+    DROP COLUMN favorite,
+    DROP COLUMN pinned;
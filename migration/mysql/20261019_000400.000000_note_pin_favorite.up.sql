@@ -0,0 +1,13 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 1;
+SET time_zone = '+00:00';
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE note
+    ADD COLUMN pinned TINYINT(1) UNSIGNED NOT NULL DEFAULT 0 AFTER version,
+    ADD COLUMN favorite TINYINT(1) UNSIGNED NOT NULL DEFAULT 0 AFTER pinned,
+    ADD INDEX `i_note_user_pinned` (`user_id`, `pinned`);
@@ -0,0 +1,16 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 0;
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE note
+    DROP INDEX `i_note_remind`,
+    DROP COLUMN reminder_sent_at,
+    DROP COLUMN reminder_claimed_at,
+    DROP COLUMN reminder_claim,
+    DROP COLUMN remind_at,
+    DROP COLUMN reminder_minutes,
+    DROP COLUMN due_at;
@@ -0,0 +1,17 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 1;
+SET time_zone = '+00:00';
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE note
+    ADD COLUMN due_at TIMESTAMP NULL DEFAULT NULL AFTER favorite,
+    ADD COLUMN reminder_minutes INT(10) UNSIGNED NULL DEFAULT NULL AFTER due_at,
+    ADD COLUMN remind_at TIMESTAMP NULL DEFAULT NULL AFTER reminder_minutes,
+    ADD COLUMN reminder_claim CHAR(32) NULL DEFAULT NULL AFTER remind_at,
+    ADD COLUMN reminder_claimed_at TIMESTAMP NULL DEFAULT NULL AFTER reminder_claim,
+    ADD COLUMN reminder_sent_at TIMESTAMP NULL DEFAULT NULL AFTER reminder_claimed_at,
+    ADD INDEX `i_note_remind` (`remind_at`, `reminder_sent_at`);
@@ -0,0 +1,17 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 0;
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE user
+    DROP FOREIGN KEY `f_user_role`,
+    DROP COLUMN role_id;
+
+# ******************************************************************************
+# Remove tables
+# ******************************************************************************
+DROP TABLE IF EXISTS note_template;
+DROP TABLE IF EXISTS user_role;
\ No newline at end of file
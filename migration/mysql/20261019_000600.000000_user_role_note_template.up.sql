@@ -0,0 +1,48 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 1;
+SET time_zone = '+00:00';
+
+# ******************************************************************************
+# Create tables
+# ******************************************************************************
+CREATE TABLE user_role (
+    id INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+    
+    role VARCHAR(25) NOT NULL,
+    
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    
+    PRIMARY KEY (id)
+);
+
+INSERT INTO `user_role` (`id`, `role`, `created_at`, `updated_at`, `deleted_at`) VALUES
+(1, 'user',  CURRENT_TIMESTAMP,  NULL,  NULL),
+(2, 'admin', CURRENT_TIMESTAMP,  NULL,  NULL);
+
+CREATE TABLE note_template (
+    id INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+    
+    name VARCHAR(100) NOT NULL,
+    body TEXT NOT NULL,
+    
+    user_id INT(10) UNSIGNED NULL DEFAULT NULL,
+    
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    
+    CONSTRAINT `f_note_template_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (id)
+);
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE user
+    ADD COLUMN role_id INT(10) UNSIGNED NOT NULL DEFAULT 1 AFTER status_id,
+    ADD CONSTRAINT `f_user_role` FOREIGN KEY (`role_id`) REFERENCES `user_role` (`id`) ON DELETE CASCADE ON UPDATE CASCADE;
\ No newline at end of file
@@ -0,0 +1,10 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 0;
+
+# ******************************************************************************
+# Remove tables
+# ******************************************************************************
+DROP TABLE IF EXISTS webhook_delivery;
+DROP TABLE IF EXISTS webhook;
\ No newline at end of file
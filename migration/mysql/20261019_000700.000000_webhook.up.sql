@@ -0,0 +1,52 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 1;
+SET time_zone = '+00:00';
+
+# ******************************************************************************
+# Create tables
+# ******************************************************************************
+CREATE TABLE webhook (
+    id INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+    
+    url VARCHAR(2048) NOT NULL,
+    secret CHAR(64) NOT NULL,
+    events VARCHAR(255) NOT NULL,
+    
+    user_id INT(10) UNSIGNED NULL DEFAULT NULL,
+    
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    
+    CONSTRAINT `f_webhook_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE webhook_delivery (
+    id INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+    
+    webhook_id INT(10) UNSIGNED NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    
+    attempts INT(10) UNSIGNED NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NULL DEFAULT NULL,
+    claim CHAR(32) NULL DEFAULT NULL,
+    claimed_at TIMESTAMP NULL DEFAULT NULL,
+    delivered_at TIMESTAMP NULL DEFAULT NULL,
+    failed_at TIMESTAMP NULL DEFAULT NULL,
+    response_status INT(10) UNSIGNED NULL DEFAULT NULL,
+    error VARCHAR(1000) NULL DEFAULT NULL,
+    
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    
+    CONSTRAINT `f_webhook_delivery_webhook` FOREIGN KEY (`webhook_id`) REFERENCES `webhook` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    INDEX `i_webhook_delivery_next` (`next_attempt_at`),
+    
+    PRIMARY KEY (id)
+);
\ No newline at end of file
@@ -0,0 +1,9 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 0;
+
+# ******************************************************************************
+# Remove tables
+# ******************************************************************************
+DROP TABLE IF EXISTS job;
\ No newline at end of file
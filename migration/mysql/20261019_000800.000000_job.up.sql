@@ -0,0 +1,30 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 1;
+SET time_zone = '+00:00';
+
+# ******************************************************************************
+# Create tables
+# ******************************************************************************
+CREATE TABLE job (
+    id INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+    
+    type VARCHAR(100) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'queued',
+    
+    attempts INT(10) UNSIGNED NOT NULL DEFAULT 0,
+    run_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_at TIMESTAMP NULL DEFAULT NULL,
+    locked_by VARCHAR(100) NULL DEFAULT NULL,
+    last_error VARCHAR(1000) NULL DEFAULT NULL,
+    failed_at TIMESTAMP NULL DEFAULT NULL,
+    
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    
+    INDEX `i_job_status_run` (`status`, `run_at`),
+    
+    PRIMARY KEY (id)
+);
\ No newline at end of file
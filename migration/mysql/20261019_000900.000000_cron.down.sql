@@ -0,0 +1,10 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 0;
+
+# ******************************************************************************
+# Remove tables
+# ******************************************************************************
+DROP TABLE IF EXISTS cron_run;
+DROP TABLE IF EXISTS cron_task;
\ No newline at end of file
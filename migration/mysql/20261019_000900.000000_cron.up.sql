@@ -0,0 +1,37 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 1;
+SET time_zone = '+00:00';
+
+# ******************************************************************************
+# Create tables
+# ******************************************************************************
+CREATE TABLE cron_task (
+    name VARCHAR(100) NOT NULL,
+    
+    expression VARCHAR(100) NOT NULL,
+    next_run_at TIMESTAMP NULL DEFAULT NULL,
+    locked_by VARCHAR(100) NULL DEFAULT NULL,
+    locked_until TIMESTAMP NULL DEFAULT NULL,
+    
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    
+    PRIMARY KEY (name)
+);
+
+CREATE TABLE cron_run (
+    id INT(10) UNSIGNED NOT NULL AUTO_INCREMENT,
+    
+    task VARCHAR(100) NOT NULL,
+    instance VARCHAR(100) NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'running',
+    error VARCHAR(1000) NULL DEFAULT NULL,
+    started_at TIMESTAMP NULL DEFAULT NULL,
+    finished_at TIMESTAMP NULL DEFAULT NULL,
+    
+    INDEX `i_cron_run_task` (`task`, `id`),
+    
+    PRIMARY KEY (id)
+);
\ No newline at end of file
@@ -0,0 +1,12 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 0;
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE webhook_delivery
+    ADD COLUMN claim CHAR(32) NULL DEFAULT NULL AFTER next_attempt_at,
+    ADD COLUMN claimed_at TIMESTAMP NULL DEFAULT NULL AFTER claim,
+    ADD INDEX `i_webhook_delivery_next` (`next_attempt_at`);
\ No newline at end of file
@@ -0,0 +1,13 @@
+# ******************************************************************************
+# Settings
+# ******************************************************************************
+SET foreign_key_checks = 1;
+SET time_zone = '+00:00';
+
+# ******************************************************************************
+# Update tables
+# ******************************************************************************
+ALTER TABLE webhook_delivery
+    DROP INDEX `i_webhook_delivery_next`,
+    DROP COLUMN claimed_at,
+    DROP COLUMN claim;
\ No newline at end of file
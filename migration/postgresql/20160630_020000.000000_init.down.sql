@@ -0,0 +1,11 @@
+-- ******************************************************************************
+-- Remove tables
+-- ******************************************************************************
+DROP TABLE IF EXISTS note CASCADE;
+DROP TABLE IF EXISTS "user" CASCADE;
+DROP TABLE IF EXISTS user_status CASCADE;
+
+-- ******************************************************************************
+-- Remove functions
+-- ******************************************************************************
+DROP FUNCTION IF EXISTS set_updated_at();
\ No newline at end of file
@@ -0,0 +1,83 @@
+-- ******************************************************************************
+-- Settings
+-- ******************************************************************************
+SET TIME ZONE 'UTC';
+
+-- ******************************************************************************
+-- Create functions
+-- ******************************************************************************
+-- set_updated_at sets updated_at when a row changes like ON UPDATE
+-- CURRENT_TIMESTAMP in MySQL.
+CREATE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ******************************************************************************
+-- Create tables
+-- ******************************************************************************
+CREATE TABLE user_status (
+    id SERIAL,
+    
+    status VARCHAR(25) NOT NULL,
+    
+    created_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE TRIGGER t_user_status_updated_at BEFORE UPDATE ON user_status
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE PROCEDURE set_updated_at();
+
+-- user is a reserved word so the name is quoted
+CREATE TABLE "user" (
+    id SERIAL,
+    
+    first_name VARCHAR(50) NOT NULL,
+    last_name VARCHAR(50) NOT NULL,
+    email VARCHAR(100) NOT NULL,
+    password CHAR(60) NOT NULL,
+    
+    status_id INTEGER NOT NULL DEFAULT 1,
+    
+    created_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    UNIQUE (email),
+    CONSTRAINT f_user_status FOREIGN KEY (status_id) REFERENCES user_status (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE TRIGGER t_user_updated_at BEFORE UPDATE ON "user"
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE PROCEDURE set_updated_at();
+
+INSERT INTO user_status (id, status, created_at, updated_at, deleted_at) VALUES
+(1, 'active',   CURRENT_TIMESTAMP,  NULL,  NULL),
+(2, 'inactive', CURRENT_TIMESTAMP,  NULL,  NULL);
+
+SELECT setval('user_status_id_seq', (SELECT MAX(id) FROM user_status));
+
+CREATE TABLE note (
+    id SERIAL,
+    
+    name TEXT NOT NULL,
+    
+    user_id INTEGER NOT NULL,
+    
+    created_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    CONSTRAINT f_note_user FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE TRIGGER t_note_updated_at BEFORE UPDATE ON note
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE PROCEDURE set_updated_at();
\ No newline at end of file
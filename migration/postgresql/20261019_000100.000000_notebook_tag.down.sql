@@ -0,0 +1,13 @@
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+ALTER TABLE note
+    DROP CONSTRAINT f_note_notebook,
+    DROP COLUMN notebook_id;
+
+-- ******************************************************************************
+-- Remove tables
+-- ******************************************************************************
+DROP TABLE IF EXISTS note_tag;
+DROP TABLE IF EXISTS tag;
+DROP TABLE IF EXISTS notebook;
\ No newline at end of file
@@ -0,0 +1,66 @@
+-- ******************************************************************************
+-- Settings
+-- ******************************************************************************
+SET TIME ZONE 'UTC';
+
+-- ******************************************************************************
+-- Create tables
+-- ******************************************************************************
+CREATE TABLE notebook (
+    id SERIAL,
+    
+    name VARCHAR(100) NOT NULL,
+    
+    user_id INTEGER NOT NULL,
+    
+    created_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    UNIQUE (user_id, name),
+    CONSTRAINT f_notebook_user FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE TRIGGER t_notebook_updated_at BEFORE UPDATE ON notebook
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE PROCEDURE set_updated_at();
+
+CREATE TABLE tag (
+    id SERIAL,
+    
+    name VARCHAR(50) NOT NULL,
+    
+    user_id INTEGER NOT NULL,
+    
+    created_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    UNIQUE (user_id, name),
+    CONSTRAINT f_tag_user FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE TRIGGER t_tag_updated_at BEFORE UPDATE ON tag
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE PROCEDURE set_updated_at();
+
+CREATE TABLE note_tag (
+    note_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    
+    created_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    
+    CONSTRAINT f_note_tag_note FOREIGN KEY (note_id) REFERENCES note (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT f_note_tag_tag FOREIGN KEY (tag_id) REFERENCES tag (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (note_id, tag_id)
+);
+
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+ALTER TABLE note
+    ADD COLUMN notebook_id INTEGER NULL DEFAULT NULL,
+    ADD CONSTRAINT f_note_notebook FOREIGN KEY (notebook_id) REFERENCES notebook (id) ON DELETE SET NULL ON UPDATE CASCADE;
\ No newline at end of file
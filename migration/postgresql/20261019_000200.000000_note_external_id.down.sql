@@ -0,0 +1,6 @@
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+ALTER TABLE note
+    DROP CONSTRAINT u_note_external,
+    DROP COLUMN external_id;
\ No newline at end of file
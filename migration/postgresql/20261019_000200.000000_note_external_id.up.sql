@@ -0,0 +1,16 @@
+-- ******************************************************************************
+-- Settings
+-- ******************************************************************************
+SET TIME ZONE 'UTC';
+
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+ALTER TABLE note
+    ADD COLUMN external_id VARCHAR(100) NULL DEFAULT NULL;
+
+UPDATE note SET external_id = md5(random()::text || id::text) WHERE external_id IS NULL;
+
+ALTER TABLE note
+    ALTER COLUMN external_id SET NOT NULL,
+    ADD CONSTRAINT u_note_external UNIQUE (user_id, external_id);
\ No newline at end of file
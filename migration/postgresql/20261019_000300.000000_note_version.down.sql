@@ -0,0 +1,5 @@
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+ALTER TABLE note
+    DROP COLUMN version;
\ No newline at end of file
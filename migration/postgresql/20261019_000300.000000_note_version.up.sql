@@ -0,0 +1,10 @@
+-- ******************************************************************************
+-- Settings
+-- ******************************************************************************
+SET TIME ZONE 'UTC';
+
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+ALTER TABLE note
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
\ No newline at end of file
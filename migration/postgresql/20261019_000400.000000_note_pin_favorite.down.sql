@@ -0,0 +1,8 @@
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+DROP INDEX IF EXISTS i_note_user_pinned;
+
+ALTER TABLE note
+    DROP COLUMN favorite,
+    DROP COLUMN pinned;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- ******************************************************************************
+-- Settings
+-- ******************************************************************************
+SET TIME ZONE 'UTC';
+
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+ALTER TABLE note
+    ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN favorite BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX i_note_user_pinned ON note (user_id, pinned);
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+DROP INDEX IF EXISTS i_note_remind;
+
+ALTER TABLE note
+    DROP COLUMN reminder_sent_at,
+    DROP COLUMN reminder_claimed_at,
+    DROP COLUMN reminder_claim,
+    DROP COLUMN remind_at,
+    DROP COLUMN reminder_minutes,
+    DROP COLUMN due_at;
\ No newline at end of file
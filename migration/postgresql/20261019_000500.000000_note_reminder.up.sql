@@ -0,0 +1,17 @@
+-- ******************************************************************************
+-- Settings
+-- ******************************************************************************
+SET TIME ZONE 'UTC';
+
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+ALTER TABLE note
+    ADD COLUMN due_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    ADD COLUMN reminder_minutes INTEGER NULL DEFAULT NULL,
+    ADD COLUMN remind_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    ADD COLUMN reminder_claim CHAR(32) NULL DEFAULT NULL,
+    ADD COLUMN reminder_claimed_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    ADD COLUMN reminder_sent_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL;
+
+CREATE INDEX i_note_remind ON note (remind_at, reminder_sent_at);
\ No newline at end of file
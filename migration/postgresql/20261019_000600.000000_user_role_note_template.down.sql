@@ -0,0 +1,12 @@
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+ALTER TABLE "user"
+    DROP CONSTRAINT f_user_role,
+    DROP COLUMN role_id;
+
+-- ******************************************************************************
+-- Remove tables
+-- ******************************************************************************
+DROP TABLE IF EXISTS note_template;
+DROP TABLE IF EXISTS user_role;
\ No newline at end of file
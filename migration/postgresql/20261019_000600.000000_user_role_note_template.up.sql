@@ -0,0 +1,55 @@
+-- ******************************************************************************
+-- Settings
+-- ******************************************************************************
+SET TIME ZONE 'UTC';
+
+-- ******************************************************************************
+-- Create tables
+-- ******************************************************************************
+CREATE TABLE user_role (
+    id SERIAL,
+    
+    role VARCHAR(25) NOT NULL,
+    
+    created_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE TRIGGER t_user_role_updated_at BEFORE UPDATE ON user_role
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE PROCEDURE set_updated_at();
+
+INSERT INTO user_role (id, role, created_at, updated_at, deleted_at) VALUES
+(1, 'user',  CURRENT_TIMESTAMP,  NULL,  NULL),
+(2, 'admin', CURRENT_TIMESTAMP,  NULL,  NULL);
+
+SELECT setval('user_role_id_seq', (SELECT MAX(id) FROM user_role));
+
+CREATE TABLE note_template (
+    id SERIAL,
+    
+    name VARCHAR(100) NOT NULL,
+    body TEXT NOT NULL,
+    
+    user_id INTEGER NULL DEFAULT NULL,
+    
+    created_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    CONSTRAINT f_note_template_user FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE TRIGGER t_note_template_updated_at BEFORE UPDATE ON note_template
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE PROCEDURE set_updated_at();
+
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+ALTER TABLE "user"
+    ADD COLUMN role_id INTEGER NOT NULL DEFAULT 1,
+    ADD CONSTRAINT f_user_role FOREIGN KEY (role_id) REFERENCES user_role (id) ON DELETE CASCADE ON UPDATE CASCADE;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- ******************************************************************************
+-- Remove tables
+-- ******************************************************************************
+DROP TABLE IF EXISTS webhook_delivery;
+DROP TABLE IF EXISTS webhook;
\ No newline at end of file
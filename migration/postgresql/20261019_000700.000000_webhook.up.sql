@@ -0,0 +1,57 @@
+-- ******************************************************************************
+-- Settings
+-- ******************************************************************************
+SET TIME ZONE 'UTC';
+
+-- ******************************************************************************
+-- Create tables
+-- ******************************************************************************
+CREATE TABLE webhook (
+    id SERIAL,
+    
+    url VARCHAR(2048) NOT NULL,
+    secret CHAR(64) NOT NULL,
+    events VARCHAR(255) NOT NULL,
+    
+    user_id INTEGER NULL DEFAULT NULL,
+    
+    created_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    CONSTRAINT f_webhook_user FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE TRIGGER t_webhook_updated_at BEFORE UPDATE ON webhook
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE PROCEDURE set_updated_at();
+
+CREATE TABLE webhook_delivery (
+    id SERIAL,
+    
+    webhook_id INTEGER NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    claim CHAR(32) NULL DEFAULT NULL,
+    claimed_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    failed_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    response_status INTEGER NULL DEFAULT NULL,
+    error VARCHAR(1000) NULL DEFAULT NULL,
+    
+    created_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    CONSTRAINT f_webhook_delivery_webhook FOREIGN KEY (webhook_id) REFERENCES webhook (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX i_webhook_delivery_next ON webhook_delivery (next_attempt_at);
+
+CREATE TRIGGER t_webhook_delivery_updated_at BEFORE UPDATE ON webhook_delivery
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE PROCEDURE set_updated_at();
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- ******************************************************************************
+-- Remove tables
+-- ******************************************************************************
+DROP TABLE IF EXISTS job;
\ No newline at end of file
@@ -0,0 +1,32 @@
+-- ******************************************************************************
+-- Settings
+-- ******************************************************************************
+SET TIME ZONE 'UTC';
+
+-- ******************************************************************************
+-- Create tables
+-- ******************************************************************************
+CREATE TABLE job (
+    id SERIAL,
+    
+    type VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'queued',
+    
+    attempts INTEGER NOT NULL DEFAULT 0,
+    run_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    locked_by VARCHAR(100) NULL DEFAULT NULL,
+    last_error VARCHAR(1000) NULL DEFAULT NULL,
+    failed_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    created_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX i_job_status_run ON job (status, run_at);
+
+CREATE TRIGGER t_job_updated_at BEFORE UPDATE ON job
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE PROCEDURE set_updated_at();
\ No newline at end of file
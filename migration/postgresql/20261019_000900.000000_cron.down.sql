@@ -0,0 +1,5 @@
+-- ******************************************************************************
+-- Remove tables
+-- ******************************************************************************
+DROP TABLE IF EXISTS cron_run;
+DROP TABLE IF EXISTS cron_task;
\ No newline at end of file
@@ -0,0 +1,39 @@
+-- ******************************************************************************
+-- Settings
+-- ******************************************************************************
+SET TIME ZONE 'UTC';
+
+-- ******************************************************************************
+-- Create tables
+-- ******************************************************************************
+CREATE TABLE cron_task (
+    name VARCHAR(100) NOT NULL,
+    
+    expression VARCHAR(100) NOT NULL,
+    next_run_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    locked_by VARCHAR(100) NULL DEFAULT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    created_at TIMESTAMP WITH TIME ZONE NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    PRIMARY KEY (name)
+);
+
+CREATE TRIGGER t_cron_task_updated_at BEFORE UPDATE ON cron_task
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE PROCEDURE set_updated_at();
+
+CREATE TABLE cron_run (
+    id SERIAL,
+    
+    task VARCHAR(100) NOT NULL,
+    instance VARCHAR(100) NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'running',
+    error VARCHAR(1000) NULL DEFAULT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+    
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX i_cron_run_task ON cron_run (task, id);
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+ALTER TABLE webhook_delivery
+    ADD COLUMN claim CHAR(32) NULL DEFAULT NULL,
+    ADD COLUMN claimed_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL;
+
+CREATE INDEX i_webhook_delivery_next ON webhook_delivery (next_attempt_at);
\ No newline at end of file
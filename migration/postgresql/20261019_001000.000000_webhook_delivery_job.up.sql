@@ -0,0 +1,13 @@
+-- ******************************************************************************
+-- Settings
+-- ******************************************************************************
+SET TIME ZONE 'UTC';
+
+-- ******************************************************************************
+-- Update tables
+-- ******************************************************************************
+DROP INDEX IF EXISTS i_webhook_delivery_next;
+
+ALTER TABLE webhook_delivery
+    DROP COLUMN claimed_at,
+    DROP COLUMN claim;
\ No newline at end of file
@@ -0,0 +1,113 @@
+// Package cronrun provides access to the cron_run table in the database.
+// Each row is the history of a single run of a recurring task.
+package cronrun
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/blue-jay/blueprint/lib/database"
+)
+
+var (
+	// table is the table name.
+	table = "cron_run"
+)
+
+const (
+	// Running is the status of a run that has not finished.
+	Running = "running"
+	// Success is the status of a run that finished without an error.
+	Success = "success"
+	// Failed is the status of a run that returned an error.
+	Failed = "failed"
+)
+
+// Item defines the model.
+type Item struct {
+	ID         uint32         `db:"id"`
+	Task       string         `db:"task"`
+	Instance   string         `db:"instance"`
+	Status     string         `db:"status"`
+	Error      sql.NullString `db:"error"`
+	StartedAt  sql.NullTime   `db:"started_at"`
+	FinishedAt sql.NullTime   `db:"finished_at"`
+}
+
+// Connection is an interface for making queries.
+type Connection interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
+}
+
+// Latest gets the latest max items.
+func Latest(db Connection, max int) ([]Item, error) {
+	var result []Item
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, task, instance, status, error, started_at, finished_at
+		FROM %v
+		ORDER BY id DESC
+		LIMIT %v
+		`, table, max)))
+	return result, err
+}
+
+// Create adds an item for a run of the task by the instance that started at
+// the time now.
+func Create(db Connection, task string, instance string, now time.Time) (sql.Result, error) {
+	result, err := database.Insert(db, fmt.Sprintf(`
+		INSERT INTO %v
+		(task, instance, status, started_at)
+		VALUES
+		(?,?,?,?)
+		`, table),
+		task, instance, Running, now.UTC())
+	return result, err
+}
+
+// Finish records the result of a run that finished at the time now. A nil
+// error is a success.
+func Finish(db Connection, ID int64, runErr error, now time.Time) (sql.Result, error) {
+	status := Success
+	var message interface{}
+	if runErr != nil {
+		status = Failed
+		message = truncate(runErr.Error())
+	}
+
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET status = ?,
+			error = ?,
+			finished_at = ?
+		WHERE id = ?
+		`, table)),
+		status, message, now.UTC(), ID)
+	return result, err
+}
+
+// DeleteBefore removes the items that started before the time.
+func DeleteBefore(db Connection, before time.Time) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		DELETE FROM %v
+		WHERE started_at < ?
+		`, table)),
+		before.UTC())
+	return result, err
+}
+
+// truncate shortens a message to fit in the error column.
+func truncate(message string) string {
+	if len(message) > 1000 {
+		message = message[:1000]
+		for !utf8.ValidString(message) {
+			message = message[:len(message)-1]
+		}
+	}
+	return message
+}
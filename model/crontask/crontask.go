@@ -0,0 +1,117 @@
+// Package crontask provides access to the cron_task table in the database.
+// Each row is a recurring task with the time of its next run and the lock of
+// the instance that is running it.
+package crontask
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/database"
+)
+
+var (
+	// table is the table name.
+	table = "cron_task"
+)
+
+// Item defines the model.
+type Item struct {
+	Name        string         `db:"name"`
+	Expression  string         `db:"expression"`
+	NextRunAt   sql.NullTime   `db:"next_run_at"`
+	LockedBy    sql.NullString `db:"locked_by"`
+	LockedUntil sql.NullTime   `db:"locked_until"`
+	CreatedAt   sql.NullTime   `db:"created_at"`
+	UpdatedAt   sql.NullTime   `db:"updated_at"`
+}
+
+// Connection is an interface for making queries.
+type Connection interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
+}
+
+// All gets all items.
+func All(db Connection) ([]Item, error) {
+	var result []Item
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT name, expression, next_run_at, locked_by, locked_until, created_at, updated_at
+		FROM %v
+		ORDER BY name
+		`, table)))
+	return result, err
+}
+
+// Upsert adds an item or changes the expression of an existing item. The next
+// run is only changed when the expression changes.
+func Upsert(db Connection, name string, expression string, nextRunAt time.Time) (sql.Result, error) {
+	update := `ON DUPLICATE KEY UPDATE
+			next_run_at = IF(expression = VALUES(expression), next_run_at, VALUES(next_run_at)),
+			expression = VALUES(expression)`
+	if database.IsPostgreSQL(db) {
+		update = fmt.Sprintf(`ON CONFLICT (name) DO UPDATE SET
+			next_run_at = CASE WHEN %v.expression = EXCLUDED.expression THEN %v.next_run_at ELSE EXCLUDED.next_run_at END,
+			expression = EXCLUDED.expression`, table, table)
+	}
+
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		INSERT INTO %v
+		(name, expression, next_run_at)
+		VALUES
+		(?,?,?)
+		%v
+		`, table, update)),
+		name, expression, nextRunAt.UTC())
+	return result, err
+}
+
+// Acquire locks an item that is due at the time now for the owner until the
+// time lockedUntil and sets its next run. Returns false if the item is not due
+// or another owner holds the lock.
+func Acquire(db Connection, name string, owner string, now time.Time, next time.Time, lockedUntil time.Time) (bool, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET next_run_at = ?,
+			locked_by = ?,
+			locked_until = ?
+		WHERE name = ?
+			AND next_run_at <= ?
+			AND (locked_until IS NULL OR locked_until < ?)
+		`, table)),
+		next.UTC(), owner, lockedUntil.UTC(), name, now.UTC(), now.UTC())
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// Release removes the lock of the owner from an item.
+func Release(db Connection, name string, owner string) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET locked_by = NULL,
+			locked_until = NULL
+		WHERE name = ?
+			AND locked_by = ?
+		`, table)),
+		name, owner)
+	return result, err
+}
+
+// RunNow makes an item due at the time now.
+func RunNow(db Connection, name string, now time.Time) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET next_run_at = ?
+		WHERE name = ?
+		`, table)),
+		now.UTC(), name)
+	return result, err
+}
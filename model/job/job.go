@@ -0,0 +1,267 @@
+// Package job provides access to the job table in the database. Jobs are
+// claimed with SELECT ... FOR UPDATE SKIP LOCKED so many workers can take jobs
+// from the table at the same time. This requires MySQL 8.0 or PostgreSQL 9.5
+// or newer.
+package job
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/blue-jay/blueprint/lib/database"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// table is the table name.
+	table = "job"
+)
+
+const (
+	// Queued is the status of a job waiting to run.
+	Queued = "queued"
+	// Running is the status of a job claimed by a worker.
+	Running = "running"
+	// Dead is the status of a job that failed too many times.
+	Dead = "dead"
+)
+
+// Item defines the model.
+type Item struct {
+	ID        uint32         `db:"id"`
+	Type      string         `db:"type"`
+	Payload   string         `db:"payload"`
+	Status    string         `db:"status"`
+	Attempts  uint32         `db:"attempts"`
+	RunAt     sql.NullTime   `db:"run_at"`
+	LockedAt  sql.NullTime   `db:"locked_at"`
+	LockedBy  sql.NullString `db:"locked_by"`
+	LastError sql.NullString `db:"last_error"`
+	FailedAt  sql.NullTime   `db:"failed_at"`
+	CreatedAt sql.NullTime   `db:"created_at"`
+	UpdatedAt sql.NullTime   `db:"updated_at"`
+}
+
+// Connection is an interface for making queries.
+type Connection interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
+}
+
+// TxConnection is an interface for making queries that can also start a
+// transaction.
+type TxConnection interface {
+	Connection
+	Beginx() (*sqlx.Tx, error)
+}
+
+// Count is the number of items with a status.
+type Count struct {
+	Status string `db:"status"`
+	Total  int    `db:"total"`
+}
+
+// ByID gets an item by ID.
+func ByID(db Connection, ID string) (Item, bool, error) {
+	result := Item{}
+	err := db.Get(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, type, payload, status, attempts, run_at, locked_at, locked_by, last_error, failed_at,
+			created_at, updated_at
+		FROM %v
+		WHERE id = ?
+		LIMIT 1
+		`, table)),
+		ID)
+	return result, err == sql.ErrNoRows, err
+}
+
+// ByStatus gets up to max items with the status, the oldest first.
+func ByStatus(db Connection, status string, max int) ([]Item, error) {
+	var result []Item
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, type, payload, status, attempts, run_at, locked_at, locked_by, last_error, failed_at,
+			created_at, updated_at
+		FROM %v
+		WHERE status = ?
+		ORDER BY run_at, id
+		LIMIT %v
+		`, table, max)),
+		status)
+	return result, err
+}
+
+// Counts gets the number of items for each status.
+func Counts(db Connection) ([]Count, error) {
+	var result []Count
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT status, COUNT(*) AS total
+		FROM %v
+		GROUP BY status
+		`, table)))
+	return result, err
+}
+
+// Create adds an item that runs at the time runAt.
+func Create(db Connection, jobType string, payload string, runAt time.Time) (sql.Result, error) {
+	result, err := database.Insert(db, fmt.Sprintf(`
+		INSERT INTO %v
+		(type, payload, run_at)
+		VALUES
+		(?,?,?)
+		`, table),
+		jobType, payload, runAt.UTC())
+	return result, err
+}
+
+// Claim marks the next item that is ready to run at the time now as running
+// by the worker and returns it. Items locked by another worker are skipped.
+// Returns true if no item is ready.
+func Claim(db TxConnection, worker string, now time.Time) (Item, bool, error) {
+	result := Item{}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return result, false, err
+	}
+
+	err = tx.Get(&result, tx.Rebind(fmt.Sprintf(`
+		SELECT id, type, payload, status, attempts, run_at, locked_at, locked_by, last_error, failed_at,
+			created_at, updated_at
+		FROM %v
+		WHERE status = ?
+			AND run_at <= ?
+		ORDER BY run_at, id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+		`, table)),
+		Queued, now.UTC())
+	if err != nil {
+		tx.Rollback()
+		return result, err == sql.ErrNoRows, err
+	}
+
+	_, err = tx.Exec(tx.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET status = ?,
+			attempts = attempts + 1,
+			locked_at = ?,
+			locked_by = ?
+		WHERE id = ?
+		`, table)),
+		Running, now.UTC(), worker, result.ID)
+	if err != nil {
+		tx.Rollback()
+		return result, false, err
+	}
+
+	result.Status = Running
+	result.Attempts++
+	return result, false, tx.Commit()
+}
+
+// Finish removes a running item of the worker after it succeeds. No rows are
+// changed when the item was released and taken by another worker.
+func Finish(db Connection, ID uint32, worker string) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		DELETE FROM %v
+		WHERE id = ?
+			AND status = ?
+			AND locked_by = ?
+		`, table)),
+		ID, Running, worker)
+	return result, err
+}
+
+// Delete removes an item that is not running.
+func Delete(db Connection, ID string) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		DELETE FROM %v
+		WHERE id = ?
+			AND status <> ?
+		`, table)),
+		ID, Running)
+	return result, err
+}
+
+// Retry queues a running item of the worker again at the time runAt after an
+// error.
+func Retry(db Connection, ID uint32, worker string, message string, runAt time.Time) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET status = ?,
+			run_at = ?,
+			locked_at = NULL,
+			locked_by = NULL,
+			last_error = ?
+		WHERE id = ?
+			AND status = ?
+			AND locked_by = ?
+		`, table)),
+		Queued, runAt.UTC(), truncate(message), ID, Running, worker)
+	return result, err
+}
+
+// Bury marks a running item of the worker as dead after an error so it is not
+// run again.
+func Bury(db Connection, ID uint32, worker string, message string, now time.Time) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET status = ?,
+			locked_at = NULL,
+			locked_by = NULL,
+			last_error = ?,
+			failed_at = ?
+		WHERE id = ?
+			AND status = ?
+			AND locked_by = ?
+		`, table)),
+		Dead, truncate(message), now.UTC(), ID, Running, worker)
+	return result, err
+}
+
+// Requeue queues a dead item again at the time now with no attempts.
+func Requeue(db Connection, ID string, now time.Time) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET status = ?,
+			attempts = 0,
+			run_at = ?,
+			failed_at = NULL
+		WHERE id = ?
+			AND status = ?
+		`, table)),
+		Queued, now.UTC(), ID, Dead)
+	return result, err
+}
+
+// ReleaseStale queues the running items that were locked before the time
+// staleBefore again. Their workers stopped before finishing.
+func ReleaseStale(db Connection, staleBefore time.Time) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET status = ?,
+			locked_at = NULL,
+			locked_by = NULL
+		WHERE status = ?
+			AND locked_at < ?
+		`, table)),
+		Queued, Running, staleBefore.UTC())
+	return result, err
+}
+
+// truncate shortens a message to fit in the last_error column.
+func truncate(message string) string {
+	if len(message) > 1000 {
+		message = message[:1000]
+		for !utf8.ValidString(message) {
+			message = message[:len(message)-1]
+		}
+	}
+	return message
+}
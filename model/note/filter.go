@@ -0,0 +1,101 @@
+package note
+
+import (
+	"strings"
+	"time"
+)
+
+var (
+	// sortColumns is the whitelist of columns an item list can be sorted by.
+	sortColumns = map[string]string{
+		"created": "n.created_at",
+		"updated": "COALESCE(n.updated_at, n.created_at)",
+		"name":    "n.name",
+	}
+
+	// sortOrders is the whitelist of sort directions.
+	sortOrders = map[string]string{
+		"asc":  "ASC",
+		"desc": "DESC",
+	}
+)
+
+const (
+	// DefaultSort is the column used when the sort is not in the whitelist.
+	DefaultSort = "created"
+	// DefaultOrder is the direction used when the order is not in the whitelist.
+	DefaultOrder = "desc"
+)
+
+// Filter defines the order and the conditions for a list of items. Only the
+// sorts and orders in the whitelist are written to the query, all other values
+// are passed as arguments.
+type Filter struct {
+	Sort     string    // created, updated, or name
+	Order    string    // asc or desc
+	From     time.Time // Created on or after this day when not zero
+	To       time.Time // Created on or before this day when not zero
+	Tag      string    // Has a tag with this name when not empty
+	Favorite bool      // Only favorites when true
+	Unpinned bool      // Only items that are not pinned when true
+}
+
+// SortKey returns the sort if it is in the whitelist or the default sort.
+func (f Filter) SortKey() string {
+	if _, ok := sortColumns[f.Sort]; ok {
+		return f.Sort
+	}
+	return DefaultSort
+}
+
+// OrderKey returns the order if it is in the whitelist or the default order.
+func (f Filter) OrderKey() string {
+	if _, ok := sortOrders[f.Order]; ok {
+		return f.Order
+	}
+	return DefaultOrder
+}
+
+// orderBy returns the ORDER BY clause built from the whitelist.
+func (f Filter) orderBy() string {
+	return "ORDER BY " + sortColumns[f.SortKey()] + " " + sortOrders[f.OrderKey()] + ", n.id " + sortOrders[f.OrderKey()]
+}
+
+// where returns the extra conditions for the WHERE clause and the arguments
+// for the placeholders.
+func (f Filter) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if !f.From.IsZero() {
+		conditions = append(conditions, "AND n.created_at >= ?")
+		args = append(args, f.From.Format("2006-01-02"))
+	}
+
+	if !f.To.IsZero() {
+		conditions = append(conditions, "AND n.created_at < ?")
+		args = append(args, f.To.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+
+	if len(f.Tag) > 0 {
+		conditions = append(conditions, `AND EXISTS (
+				SELECT 1
+				FROM note_tag AS nt
+				INNER JOIN tag AS t ON t.id = nt.tag_id
+				WHERE nt.note_id = n.id
+					AND t.name = ?
+					AND t.deleted_at IS NULL
+			)`)
+		args = append(args, f.Tag)
+	}
+
+	if f.Favorite {
+		conditions = append(conditions, "AND n.favorite = TRUE")
+	}
+
+	if f.Unpinned {
+		conditions = append(conditions, "AND n.pinned = FALSE")
+	}
+
+	return strings.Join(conditions, "\n\t\t\t"), args
+}
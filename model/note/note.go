@@ -1,26 +1,47 @@
-// Package note provides access to the note table in the MySQL database.
+// Package note provides access to the note table in the database.
 package note
 
 import (
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"time"
 
-	"github.com/go-sql-driver/mysql"
+	"github.com/blue-jay/blueprint/lib/database"
+	"github.com/blue-jay/blueprint/model/notebook"
+	"github.com/blue-jay/blueprint/model/tag"
+
+	"github.com/jmoiron/sqlx"
 )
 
 var (
 	// table is the table name.
 	table = "note"
+
+	// ErrConflict is returned when an item was changed by someone else after
+	// it was read.
+	ErrConflict = errors.New("note was changed by another request")
 )
 
 // Item defines the model.
 type Item struct {
-	ID        uint32         `db:"id"`
-	Name      string         `db:"name"`
-	UserID    uint32         `db:"user_id"`
-	CreatedAt mysql.NullTime `db:"created_at"`
-	UpdatedAt mysql.NullTime `db:"updated_at"`
-	DeletedAt mysql.NullTime `db:"deleted_at"`
+	ID           uint32         `db:"id"`
+	Name         string         `db:"name"`
+	UserID       uint32         `db:"user_id"`
+	NotebookID   sql.NullInt64  `db:"notebook_id"`
+	NotebookName sql.NullString `db:"notebook_name"`
+	ExternalID   string         `db:"external_id"`
+	Version      uint32         `db:"version"`
+	Pinned       bool           `db:"pinned"`
+	Favorite     bool           `db:"favorite"`
+	DueAt        sql.NullTime   `db:"due_at"`
+	RemindBefore sql.NullInt64  `db:"reminder_minutes"`
+	RemindedAt   sql.NullTime   `db:"reminder_sent_at"`
+	CreatedAt    sql.NullTime   `db:"created_at"`
+	UpdatedAt    sql.NullTime   `db:"updated_at"`
+	DeletedAt    sql.NullTime   `db:"deleted_at"`
 }
 
 // Connection is an interface for making queries.
@@ -28,19 +49,64 @@ type Connection interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	Get(dest interface{}, query string, args ...interface{}) error
 	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
+}
+
+// TxConnection is an interface for making queries that can also start a
+// transaction.
+type TxConnection interface {
+	Connection
+	Beginx() (*sqlx.Tx, error)
+}
+
+// Transaction runs fn inside of a transaction and commits if fn does not
+// return an error.
+func Transaction(db TxConnection, fn func(tx Connection) error) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// each runs fn for every ID and returns the total number of rows changed.
+func each(IDs []string, fn func(ID string) (sql.Result, error)) (int64, error) {
+	var total int64
+	for _, ID := range IDs {
+		result, err := fn(ID)
+		if err != nil {
+			return total, err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += rows
+	}
+	return total, nil
 }
 
 // ByID gets an item by ID.
 func ByID(db Connection, ID string, userID string) (Item, bool, error) {
 	result := Item{}
-	err := db.Get(&result, fmt.Sprintf(`
-		SELECT id, name, user_id, created_at, updated_at, deleted_at
-		FROM %v
-		WHERE id = ?
-			AND user_id = ?
-			AND deleted_at IS NULL
+	err := db.Get(&result, db.Rebind(fmt.Sprintf(`
+		SELECT n.id, n.name, n.user_id, n.notebook_id, nb.name AS notebook_name, n.external_id, n.version, n.pinned, n.favorite, n.due_at, n.reminder_minutes, n.reminder_sent_at,
+			n.created_at, n.updated_at, n.deleted_at
+		FROM %v AS n
+		LEFT JOIN notebook AS nb ON nb.id = n.notebook_id
+		WHERE n.id = ?
+			AND n.user_id = ?
+			AND n.deleted_at IS NULL
 		LIMIT 1
-		`, table),
+		`, table)),
 		ID, userID)
 	return result, err == sql.ErrNoRows, err
 }
@@ -48,91 +114,384 @@ func ByID(db Connection, ID string, userID string) (Item, bool, error) {
 // ByUserID gets all items for a user.
 func ByUserID(db Connection, userID string) ([]Item, bool, error) {
 	var result []Item
-	err := db.Select(&result, fmt.Sprintf(`
-		SELECT id, name, user_id, created_at, updated_at, deleted_at
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, name, user_id, notebook_id, external_id, version, pinned, favorite, due_at, reminder_minutes, reminder_sent_at, created_at, updated_at, deleted_at
 		FROM %v
 		WHERE user_id = ?
 			AND deleted_at IS NULL
-		`, table),
+		`, table)),
 		userID)
 	return result, err == sql.ErrNoRows, err
 }
 
-// ByUserIDPaginate gets items for a user based on page and max variables.
-func ByUserIDPaginate(db Connection, userID string, max int, page int) ([]Item, bool, error) {
+// ByUserIDPinned gets all pinned items for a user based on the filter.
+func ByUserIDPinned(db Connection, userID string, filter Filter) ([]Item, bool, error) {
 	var result []Item
-	err := db.Select(&result, fmt.Sprintf(`
-		SELECT id, name, user_id, created_at, updated_at, deleted_at
-		FROM %v
-		WHERE user_id = ?
-			AND deleted_at IS NULL
+	where, args := filter.where()
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT n.id, n.name, n.user_id, n.notebook_id, nb.name AS notebook_name, n.external_id, n.version, n.pinned, n.favorite, n.due_at, n.reminder_minutes, n.reminder_sent_at,
+			n.created_at, n.updated_at, n.deleted_at
+		FROM %v AS n
+		LEFT JOIN notebook AS nb ON nb.id = n.notebook_id
+		WHERE n.user_id = ?
+			AND n.pinned = TRUE
+			AND n.deleted_at IS NULL
+			%v
+		%v
+		`, table, where, filter.orderBy())),
+		append([]interface{}{userID}, args...)...)
+	return result, err == sql.ErrNoRows, err
+}
+
+// ByUserIDPaginate gets items for a user based on the filter and the page and
+// max variables.
+func ByUserIDPaginate(db Connection, userID string, filter Filter, max int, page int) ([]Item, bool, error) {
+	var result []Item
+	where, args := filter.where()
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT n.id, n.name, n.user_id, n.notebook_id, nb.name AS notebook_name, n.external_id, n.version, n.pinned, n.favorite, n.due_at, n.reminder_minutes, n.reminder_sent_at,
+			n.created_at, n.updated_at, n.deleted_at
+		FROM %v AS n
+		LEFT JOIN notebook AS nb ON nb.id = n.notebook_id
+		WHERE n.user_id = ?
+			AND n.deleted_at IS NULL
+			%v
+		%v
+		LIMIT %v OFFSET %v
+		`, table, where, filter.orderBy(), max, page)),
+		append([]interface{}{userID}, args...)...)
+	return result, err == sql.ErrNoRows, err
+}
+
+// DeletedByUserIDPaginate gets removed items for a user based on page and max
+// variables.
+func DeletedByUserIDPaginate(db Connection, userID string, max int, page int) ([]Item, bool, error) {
+	var result []Item
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT n.id, n.name, n.user_id, n.notebook_id, nb.name AS notebook_name, n.external_id, n.version, n.pinned, n.favorite, n.due_at, n.reminder_minutes, n.reminder_sent_at,
+			n.created_at, n.updated_at, n.deleted_at
+		FROM %v AS n
+		LEFT JOIN notebook AS nb ON nb.id = n.notebook_id
+		WHERE n.user_id = ?
+			AND n.deleted_at IS NOT NULL
+		ORDER BY n.deleted_at DESC
 		LIMIT %v OFFSET %v
-		`, table, max, page),
+		`, table, max, page)),
 		userID)
 	return result, err == sql.ErrNoRows, err
 }
 
-// ByUserIDCount counts the number of items for a user.
-func ByUserIDCount(db Connection, userID string) (int, error) {
+// ByUserIDCount counts the number of items for a user based on the filter.
+func ByUserIDCount(db Connection, userID string, filter Filter) (int, error) {
 	var result int
-	err := db.Get(&result, fmt.Sprintf(`
+	where, args := filter.where()
+	err := db.Get(&result, db.Rebind(fmt.Sprintf(`
+		SELECT count(*)
+		FROM %v AS n
+		WHERE n.user_id = ?
+			AND n.deleted_at IS NULL
+			%v
+		`, table, where)),
+		append([]interface{}{userID}, args...)...)
+	return result, err
+}
+
+// DeletedByUserIDCount counts the number of removed items for a user.
+func DeletedByUserIDCount(db Connection, userID string) (int, error) {
+	var result int
+	err := db.Get(&result, db.Rebind(fmt.Sprintf(`
 		SELECT count(*)
 		FROM %v
 		WHERE user_id = ?
-			AND deleted_at IS NULL
-		`, table),
+			AND deleted_at IS NOT NULL
+		`, table)),
 		userID)
 	return result, err
 }
 
+// ByExternalID gets an item by external ID, including an item that was
+// removed.
+func ByExternalID(db Connection, externalID string, userID string) (Item, bool, error) {
+	result := Item{}
+	err := db.Get(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, name, user_id, notebook_id, external_id, version, pinned, favorite, due_at, reminder_minutes, reminder_sent_at, created_at, updated_at, deleted_at
+		FROM %v
+		WHERE external_id = ?
+			AND user_id = ?
+		LIMIT 1
+		`, table)),
+		externalID, userID)
+	return result, err == sql.ErrNoRows, err
+}
+
+// ByUserIDCursor gets up to max items for a user with an ID greater than
+// afterID ordered by ID.
+func ByUserIDCursor(db Connection, userID string, afterID uint32, max int) ([]Item, bool, error) {
+	var result []Item
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT n.id, n.name, n.user_id, n.notebook_id, nb.name AS notebook_name, n.external_id, n.version, n.pinned, n.favorite, n.due_at, n.reminder_minutes, n.reminder_sent_at,
+			n.created_at, n.updated_at, n.deleted_at
+		FROM %v AS n
+		LEFT JOIN notebook AS nb ON nb.id = n.notebook_id
+		WHERE n.user_id = ?
+			AND n.id > ?
+			AND n.deleted_at IS NULL
+		ORDER BY n.id
+		LIMIT %v
+		`, table, max)),
+		userID, afterID)
+	return result, err == sql.ErrNoRows, err
+}
+
+// NewExternalID returns a random external ID.
+func NewExternalID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
 // Create adds an item.
 func Create(db Connection, name string, userID string) (sql.Result, error) {
-	result, err := db.Exec(fmt.Sprintf(`
+	result, err := database.Insert(db, fmt.Sprintf(`
 		INSERT INTO %v
-		(name, user_id)
+		(name, user_id, external_id)
 		VALUES
-		(?,?)
+		(?,?,?)
 		`, table),
-		name, userID)
+		name, userID, NewExternalID())
 	return result, err
 }
 
-// Update makes changes to an existing item.
-func Update(db Connection, name string, ID string, userID string) (sql.Result, error) {
-	result, err := db.Exec(fmt.Sprintf(`
+// Upsert adds an item or changes the item with the same external ID. An item
+// that was removed is restored. The created time is only used when the item
+// is added and is ignored if zero. Returns the ID of the item and true if the
+// item was added.
+func Upsert(db Connection, externalID string, name string, userID string, createdAt time.Time) (string, bool, error) {
+	item, noRows, err := ByExternalID(db, externalID, userID)
+	if err == nil {
+		_, err = db.Exec(db.Rebind(fmt.Sprintf(`
+			UPDATE %v
+			SET name = ?,
+				version = version + 1,
+				deleted_at = NULL
+			WHERE id = ?
+				AND user_id = ?
+			`, table)),
+			name, item.ID, userID)
+		return fmt.Sprintf("%v", item.ID), false, err
+	} else if !noRows {
+		return "", false, err
+	}
+
+	var created interface{}
+	if !createdAt.IsZero() {
+		created = createdAt.UTC()
+	}
+
+	result, err := database.Insert(db, fmt.Sprintf(`
+		INSERT INTO %v
+		(name, user_id, external_id, created_at)
+		VALUES
+		(?,?,?,COALESCE(?, CURRENT_TIMESTAMP))
+		`, table),
+		name, userID, externalID, created)
+	if err != nil {
+		return "", false, err
+	}
+
+	ID, err := result.LastInsertId()
+	return fmt.Sprintf("%v", ID), true, err
+}
+
+// Update makes changes to an existing item only if the version matches the
+// version that was read. Returns ErrConflict if the item was changed since.
+func Update(db Connection, name string, ID string, userID string, version string) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
 		UPDATE %v
-		SET name = ?
+		SET name = ?,
+			version = version + 1
 		WHERE id = ?
 			AND user_id = ?
+			AND version = ?
 			AND deleted_at IS NULL
-		LIMIT 1
-		`, table),
-		name, ID, userID)
-	return result, err
+		`, table)),
+		name, ID, userID, version)
+	if err != nil {
+		return result, err
+	}
+
+	if rows, err := result.RowsAffected(); err != nil {
+		return result, err
+	} else if rows == 0 {
+		return result, ErrConflict
+	}
+
+	return result, nil
 }
 
 // DeleteHard removes an item.
 func DeleteHard(db Connection, ID string, userID string) (sql.Result, error) {
-	result, err := db.Exec(fmt.Sprintf(`
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
 		DELETE FROM %v
 		WHERE id = ?
 			AND user_id = ?
 			AND deleted_at IS NULL
-		`, table),
+		`, table)),
 		ID, userID)
 	return result, err
 }
 
 // DeleteSoft marks an item as removed.
 func DeleteSoft(db Connection, ID string, userID string) (sql.Result, error) {
-	result, err := db.Exec(fmt.Sprintf(`
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
 		UPDATE %v
 		SET deleted_at = NOW()
 		WHERE id = ?
 			AND user_id = ?
 			AND deleted_at IS NULL
-		LIMIT 1
-		`, table),
+		`, table)),
 		ID, userID)
 	return result, err
 }
+
+// PurgeDeleted removes the items of the user that are marked as removed.
+func PurgeDeleted(db Connection, userID string) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		DELETE FROM %v
+		WHERE user_id = ?
+			AND deleted_at IS NOT NULL
+		`, table)),
+		userID)
+	return result, err
+}
+
+// PurgeDeletedBefore removes the items of every user that were marked as
+// removed before the time.
+func PurgeDeletedBefore(db Connection, before time.Time) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		DELETE FROM %v
+		WHERE deleted_at < ?
+		`, table)),
+		before.UTC())
+	return result, err
+}
+
+// Restore unmarks a removed item.
+func Restore(db Connection, ID string, userID string) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET deleted_at = NULL
+		WHERE id = ?
+			AND user_id = ?
+			AND deleted_at IS NOT NULL
+		`, table)),
+		ID, userID)
+	return result, err
+}
+
+// SetPinned pins or unpins an existing item.
+func SetPinned(db Connection, pinned bool, ID string, userID string) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET pinned = ?
+		WHERE id = ?
+			AND user_id = ?
+			AND deleted_at IS NULL
+		`, table)),
+		pinned, ID, userID)
+	return result, err
+}
+
+// SetFavorite marks or unmarks an existing item as a favorite.
+func SetFavorite(db Connection, favorite bool, ID string, userID string) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET favorite = ?
+		WHERE id = ?
+			AND user_id = ?
+			AND deleted_at IS NULL
+		`, table)),
+		favorite, ID, userID)
+	return result, err
+}
+
+// Move changes the notebook of an existing item. An empty notebook ID removes
+// the item from its notebook.
+func Move(db Connection, notebookID string, ID string, userID string) (sql.Result, error) {
+	var value interface{}
+	if len(notebookID) > 0 {
+		value = notebookID
+	}
+
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET notebook_id = ?
+		WHERE id = ?
+			AND user_id = ?
+			AND deleted_at IS NULL
+		`, table)),
+		value, ID, userID)
+	return result, err
+}
+
+// BulkDeleteSoft marks items as removed in a single transaction. Returns the
+// number of items changed.
+func BulkDeleteSoft(db TxConnection, IDs []string, userID string) (int64, error) {
+	var total int64
+	err := Transaction(db, func(tx Connection) (err error) {
+		total, err = each(IDs, func(ID string) (sql.Result, error) {
+			return DeleteSoft(tx, ID, userID)
+		})
+		return err
+	})
+	return total, err
+}
+
+// BulkRestore unmarks removed items in a single transaction. Returns the
+// number of items changed.
+func BulkRestore(db TxConnection, IDs []string, userID string) (int64, error) {
+	var total int64
+	err := Transaction(db, func(tx Connection) (err error) {
+		total, err = each(IDs, func(ID string) (sql.Result, error) {
+			return Restore(tx, ID, userID)
+		})
+		return err
+	})
+	return total, err
+}
+
+// BulkMove moves items to a notebook in a single transaction. The notebook is
+// created if it does not exist. Returns the number of items changed.
+func BulkMove(db TxConnection, IDs []string, notebookName string, userID string) (int64, error) {
+	var total int64
+	err := Transaction(db, func(tx Connection) error {
+		notebookID, err := notebook.FirstOrCreate(tx, notebookName, userID)
+		if err != nil {
+			return err
+		}
+
+		total, err = each(IDs, func(ID string) (sql.Result, error) {
+			return Move(tx, notebookID, ID, userID)
+		})
+		return err
+	})
+	return total, err
+}
+
+// BulkTag attaches a tag to items in a single transaction. The tag is created
+// if it does not exist. Returns the number of items changed.
+func BulkTag(db TxConnection, IDs []string, tagName string, userID string) (int64, error) {
+	var total int64
+	err := Transaction(db, func(tx Connection) error {
+		tagID, err := tag.FirstOrCreate(tx, tagName, userID)
+		if err != nil {
+			return err
+		}
+
+		total, err = each(IDs, func(ID string) (sql.Result, error) {
+			return tag.Attach(tx, tagID, ID, userID)
+		})
+		return err
+	})
+	return total, err
+}
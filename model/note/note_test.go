@@ -3,7 +3,9 @@ package note_test
 import (
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/blue-jay/blueprint/model/note"
 	"github.com/blue-jay/blueprint/model/user"
@@ -37,6 +39,24 @@ func teardown() {
 	mysql.TearDown(db, "database_test")
 }
 
+// createUser adds a user with an email unique to the test and returns the ID
+// and the email.
+func createUser(t *testing.T) (string, string) {
+	email := fmt.Sprintf("%v@domain.com", strings.ToLower(t.Name()))
+
+	result, err := user.Create(db, "John", "Doe", email, "p@$$W0rD")
+	if err != nil {
+		t.Fatal("could not create user:", err)
+	}
+
+	uID, err := result.LastInsertId()
+	if err != nil {
+		t.Fatal("could not convert user ID:", err)
+	}
+
+	return fmt.Sprintf("%v", uID), email
+}
+
 // TestComplete
 func TestComplete(t *testing.T) {
 	data := "Test data."
@@ -79,11 +99,17 @@ func TestComplete(t *testing.T) {
 	}
 
 	// Update a record
-	result, err = note.Update(db, dataNew, lastID, userID)
+	result, err = note.Update(db, dataNew, lastID, userID, fmt.Sprintf("%v", record.Version))
 	if err != nil {
 		t.Error("could not update record:", err)
 	}
 
+	// Update a record with the old version
+	_, err = note.Update(db, "Stale data.", lastID, userID, fmt.Sprintf("%v", record.Version))
+	if err != note.ErrConflict {
+		t.Errorf("stale update should conflict: got '%v' want '%v'", err, note.ErrConflict)
+	}
+
 	// Select a record
 	record, _, err = note.ByID(db, lastID, userID)
 	if err != nil {
@@ -106,3 +132,230 @@ func TestComplete(t *testing.T) {
 		t.Error("incorrect number of affected rows:", rows)
 	}
 }
+
+// TestBulk ensures the bulk actions change every item in the list.
+func TestBulk(t *testing.T) {
+	userID, _ := createUser(t)
+
+	// Create the records
+	var IDs []string
+	for i := 0; i < 3; i++ {
+		result, err := note.Create(db, fmt.Sprintf("Bulk data %v.", i), userID)
+		if err != nil {
+			t.Fatal("could not create record:", err)
+		}
+
+		ID, err := result.LastInsertId()
+		if err != nil {
+			t.Fatal("could not convert ID:", err)
+		}
+		IDs = append(IDs, fmt.Sprintf("%v", ID))
+	}
+
+	// Move the records to a notebook
+	count, err := note.BulkMove(db, IDs, "Runbooks", userID)
+	if err != nil {
+		t.Error("could not move records:", err)
+	} else if count != 3 {
+		t.Error("incorrect number of moved rows:", count)
+	}
+
+	record, _, err := note.ByID(db, IDs[0], userID)
+	if err != nil {
+		t.Error("could not retrieve record:", err)
+	} else if record.NotebookName.String != "Runbooks" {
+		t.Errorf("retrieved wrong notebook: got '%v' want '%v'", record.NotebookName.String, "Runbooks")
+	}
+
+	// Tag the records twice to ensure tags are not duplicated
+	for i := 0; i < 2; i++ {
+		_, err = note.BulkTag(db, IDs, "ops", userID)
+		if err != nil {
+			t.Error("could not tag records:", err)
+		}
+	}
+
+	// Delete the records
+	count, err = note.BulkDeleteSoft(db, IDs, userID)
+	if err != nil {
+		t.Error("could not delete records:", err)
+	} else if count != 3 {
+		t.Error("incorrect number of deleted rows:", count)
+	}
+
+	// Restore the records
+	count, err = note.BulkRestore(db, IDs, userID)
+	if err != nil {
+		t.Error("could not restore records:", err)
+	} else if count != 3 {
+		t.Error("incorrect number of restored rows:", count)
+	}
+}
+
+// TestFilter ensures items are sorted and filtered and that values outside of
+// the whitelist are not written to the query.
+func TestFilter(t *testing.T) {
+	userID, _ := createUser(t)
+
+	// Create the records
+	var IDs []string
+	for _, name := range []string{"b", "a", "c"} {
+		result, err := note.Create(db, name, userID)
+		if err != nil {
+			t.Fatal("could not create record:", err)
+		}
+
+		ID, err := result.LastInsertId()
+		if err != nil {
+			t.Fatal("could not convert ID:", err)
+		}
+		IDs = append(IDs, fmt.Sprintf("%v", ID))
+	}
+
+	// Tag the first two records
+	_, err := note.BulkTag(db, IDs[:2], "keep", userID)
+	if err != nil {
+		t.Fatal("could not tag records:", err)
+	}
+
+	// Sort by name
+	records, _, err := note.ByUserIDPaginate(db, userID, note.Filter{Sort: "name", Order: "asc"}, 10, 0)
+	if err != nil {
+		t.Fatal("could not retrieve records:", err)
+	} else if len(records) != 3 || records[0].Name != "a" || records[2].Name != "c" {
+		t.Errorf("retrieved records in wrong order: %v", records)
+	}
+
+	// Filter by tag
+	count, err := note.ByUserIDCount(db, userID, note.Filter{Tag: "keep"})
+	if err != nil {
+		t.Error("could not count records:", err)
+	} else if count != 2 {
+		t.Error("incorrect number of tagged records:", count)
+	}
+
+	// Ensure an invalid sort falls back to the default
+	f := note.Filter{Sort: "name; DROP TABLE note", Order: "sideways"}
+	if f.SortKey() != note.DefaultSort || f.OrderKey() != note.DefaultOrder {
+		t.Errorf("invalid sort was accepted: %v %v", f.SortKey(), f.OrderKey())
+	}
+
+	_, _, err = note.ByUserIDPaginate(db, userID, f, 10, 0)
+	if err != nil {
+		t.Error("could not retrieve records with invalid sort:", err)
+	}
+
+	// Pin a record so it is listed separately from the pages
+	_, err = note.SetPinned(db, true, IDs[2], userID)
+	if err != nil {
+		t.Fatal("could not pin record:", err)
+	}
+
+	pinned, _, err := note.ByUserIDPinned(db, userID, note.Filter{})
+	if err != nil {
+		t.Error("could not retrieve pinned records:", err)
+	} else if len(pinned) != 1 || !pinned[0].Pinned {
+		t.Errorf("retrieved wrong pinned records: %v", pinned)
+	}
+
+	count, err = note.ByUserIDCount(db, userID, note.Filter{Unpinned: true})
+	if err != nil {
+		t.Error("could not count records:", err)
+	} else if count != 2 {
+		t.Error("incorrect number of records that are not pinned:", count)
+	}
+
+	count, err = note.ByUserIDCount(db, userID, note.Filter{})
+	if err != nil {
+		t.Error("could not count records:", err)
+	} else if count != 3 {
+		t.Error("incorrect number of records:", count)
+	}
+}
+
+// TestUpsert ensures an item with the same external ID is only added once.
+func TestUpsert(t *testing.T) {
+	userID, _ := createUser(t)
+
+	firstID, added, err := note.Upsert(db, "ext-1", "Imported data.", userID, time.Time{})
+	if err != nil {
+		t.Fatal("could not add record:", err)
+	} else if !added {
+		t.Error("record should have been added")
+	}
+
+	secondID, added, err := note.Upsert(db, "ext-1", "Imported again.", userID, time.Time{})
+	if err != nil {
+		t.Fatal("could not update record:", err)
+	} else if added {
+		t.Error("record should have been updated")
+	} else if firstID != secondID {
+		t.Errorf("updated wrong record: got '%v' want '%v'", secondID, firstID)
+	}
+
+	record, _, err := note.ByID(db, firstID, userID)
+	if err != nil {
+		t.Error("could not retrieve record:", err)
+	} else if record.Name != "Imported again." {
+		t.Errorf("retrieved wrong record: got '%v' want '%v'", record.Name, "Imported again.")
+	}
+}
+
+// TestReminder ensures a reminder is only claimed and sent once.
+func TestReminder(t *testing.T) {
+	userID, email := createUser(t)
+
+	result, err := note.Create(db, "Reminder data.", userID)
+	if err != nil {
+		t.Fatal("could not create record:", err)
+	}
+
+	ID, err := result.LastInsertId()
+	if err != nil {
+		t.Fatal("could not convert ID:", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	dueAt := now.Add(30 * time.Minute)
+	minutes := 60
+
+	_, err = note.SetReminder(db, &dueAt, &minutes, fmt.Sprintf("%v", ID), userID)
+	if err != nil {
+		t.Fatal("could not set reminder:", err)
+	}
+
+	stale := now.Add(-5 * time.Minute)
+	items, err := note.DueReminders(db, now, stale, 10)
+	if err != nil {
+		t.Fatal("could not retrieve reminders:", err)
+	} else if len(items) != 1 || items[0].Email != email {
+		t.Fatalf("retrieved wrong reminders: %v", items)
+	}
+
+	ok, err := note.ClaimReminder(db, items[0].ID, "first", now, stale)
+	if err != nil {
+		t.Fatal("could not claim reminder:", err)
+	} else if !ok {
+		t.Error("reminder should have been claimed")
+	}
+
+	ok, err = note.ClaimReminder(db, items[0].ID, "second", now, stale)
+	if err != nil {
+		t.Fatal("could not claim reminder:", err)
+	} else if ok {
+		t.Error("reminder should not be claimed twice")
+	}
+
+	_, err = note.MarkReminderSent(db, items[0].ID, "first", now)
+	if err != nil {
+		t.Fatal("could not mark reminder sent:", err)
+	}
+
+	// A claim from a stopped sender must not resend a sent reminder
+	items, err = note.DueReminders(db, now.Add(time.Hour), now.Add(time.Hour), 10)
+	if err != nil {
+		t.Error("could not retrieve reminders:", err)
+	} else if len(items) != 0 {
+		t.Errorf("reminder should not be sent twice: %v", items)
+	}
+}
@@ -0,0 +1,115 @@
+package note
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/blue-jay/blueprint/lib/database"
+)
+
+// Reminder defines a reminder that is ready to be sent.
+type Reminder struct {
+	ID        uint32       `db:"id"`
+	Name      string       `db:"name"`
+	DueAt     sql.NullTime `db:"due_at"`
+	Email     string       `db:"email"`
+	FirstName string       `db:"first_name"`
+}
+
+// SetReminder sets the due time of an item and the number of minutes before
+// the due time to send a reminder. A nil due time removes the due time and the
+// reminder. A nil minutes means no reminder is sent. The reminder is sent
+// again after it is changed.
+func SetReminder(db Connection, dueAt *time.Time, minutes *int, ID string, userID string) (sql.Result, error) {
+	var due, remindAt, before interface{}
+	if dueAt != nil {
+		due = dueAt.UTC()
+		if minutes != nil {
+			before = *minutes
+			remindAt = dueAt.Add(-time.Duration(*minutes) * time.Minute).UTC()
+		}
+	}
+
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET due_at = ?,
+			reminder_minutes = ?,
+			remind_at = ?,
+			reminder_claim = NULL,
+			reminder_claimed_at = NULL,
+			reminder_sent_at = NULL
+		WHERE id = ?
+			AND user_id = ?
+			AND deleted_at IS NULL
+		`, table)),
+		due, before, remindAt, ID, userID)
+	return result, err
+}
+
+// DueReminders gets up to max reminders that should be sent at the time now
+// and are not claimed by another sender. A claim older than staleBefore is
+// ignored so reminders are not lost when a sender stops before finishing.
+func DueReminders(db Connection, now time.Time, staleBefore time.Time, max int) ([]Reminder, error) {
+	var result []Reminder
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT n.id, n.name, n.due_at, u.email, u.first_name
+		FROM %v AS n
+		INNER JOIN %v AS u ON u.id = n.user_id
+		WHERE n.remind_at <= ?
+			AND n.reminder_sent_at IS NULL
+			AND (n.reminder_claim IS NULL OR n.reminder_claimed_at < ?)
+			AND n.deleted_at IS NULL
+			AND u.deleted_at IS NULL
+		ORDER BY n.remind_at
+		LIMIT %v
+		`, table, database.Quote(db, "user"), max)),
+		now.UTC(), staleBefore.UTC())
+	return result, err
+}
+
+// ClaimReminder marks a reminder as being sent by the owner of the claim.
+// Returns false if another sender claimed or sent the reminder first.
+func ClaimReminder(db Connection, ID uint32, claim string, now time.Time, staleBefore time.Time) (bool, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET reminder_claim = ?,
+			reminder_claimed_at = ?
+		WHERE id = ?
+			AND reminder_sent_at IS NULL
+			AND (reminder_claim IS NULL OR reminder_claimed_at < ?)
+		`, table)),
+		claim, now.UTC(), ID, staleBefore.UTC())
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// ReleaseReminder removes a claim so the reminder can be sent again.
+func ReleaseReminder(db Connection, ID uint32, claim string) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET reminder_claim = NULL,
+			reminder_claimed_at = NULL
+		WHERE id = ?
+			AND reminder_claim = ?
+		`, table)),
+		ID, claim)
+	return result, err
+}
+
+// MarkReminderSent records that the owner of the claim sent the reminder. No
+// rows are changed when the claim was taken by another scheduler.
+func MarkReminderSent(db Connection, ID uint32, claim string, now time.Time) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET reminder_sent_at = ?
+		WHERE id = ?
+			AND reminder_claim = ?
+		`, table)),
+		now.UTC(), ID, claim)
+	return result, err
+}
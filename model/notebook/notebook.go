@@ -0,0 +1,93 @@
+// Package notebook provides access to the notebook table in the database.
+package notebook
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/blue-jay/blueprint/lib/database"
+)
+
+var (
+	// table is the table name.
+	table = "notebook"
+)
+
+// Item defines the model.
+type Item struct {
+	ID        uint32       `db:"id"`
+	Name      string       `db:"name"`
+	UserID    uint32       `db:"user_id"`
+	CreatedAt sql.NullTime `db:"created_at"`
+	UpdatedAt sql.NullTime `db:"updated_at"`
+	DeletedAt sql.NullTime `db:"deleted_at"`
+}
+
+// Connection is an interface for making queries.
+type Connection interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
+}
+
+// ByName gets an item by name.
+func ByName(db Connection, name string, userID string) (Item, bool, error) {
+	result := Item{}
+	err := db.Get(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, name, user_id, created_at, updated_at, deleted_at
+		FROM %v
+		WHERE name = ?
+			AND user_id = ?
+			AND deleted_at IS NULL
+		LIMIT 1
+		`, table)),
+		name, userID)
+	return result, err == sql.ErrNoRows, err
+}
+
+// ByUserID gets all items for a user.
+func ByUserID(db Connection, userID string) ([]Item, bool, error) {
+	var result []Item
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, name, user_id, created_at, updated_at, deleted_at
+		FROM %v
+		WHERE user_id = ?
+			AND deleted_at IS NULL
+		ORDER BY name
+		`, table)),
+		userID)
+	return result, err == sql.ErrNoRows, err
+}
+
+// Create adds an item.
+func Create(db Connection, name string, userID string) (sql.Result, error) {
+	result, err := database.Insert(db, fmt.Sprintf(`
+		INSERT INTO %v
+		(name, user_id)
+		VALUES
+		(?,?)
+		`, table),
+		name, userID)
+	return result, err
+}
+
+// FirstOrCreate gets an item by name and adds it if it does not exist. Returns
+// the ID of the item.
+func FirstOrCreate(db Connection, name string, userID string) (string, error) {
+	item, noRows, err := ByName(db, name, userID)
+	if err == nil {
+		return fmt.Sprintf("%v", item.ID), nil
+	} else if !noRows {
+		return "", err
+	}
+
+	result, err := Create(db, name, userID)
+	if err != nil {
+		return "", err
+	}
+
+	ID, err := result.LastInsertId()
+	return fmt.Sprintf("%v", ID), err
+}
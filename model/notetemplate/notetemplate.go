@@ -0,0 +1,157 @@
+// Package notetemplate provides access to the note_template table in the
+// database. A template without a user is global and available to every user.
+package notetemplate
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/blue-jay/blueprint/lib/database"
+)
+
+var (
+	// table is the table name.
+	table = "note_template"
+)
+
+// Item defines the model.
+type Item struct {
+	ID        uint32        `db:"id"`
+	Name      string        `db:"name"`
+	Body      string        `db:"body"`
+	UserID    sql.NullInt64 `db:"user_id"`
+	CreatedAt sql.NullTime  `db:"created_at"`
+	UpdatedAt sql.NullTime  `db:"updated_at"`
+	DeletedAt sql.NullTime  `db:"deleted_at"`
+}
+
+// Connection is an interface for making queries.
+type Connection interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
+}
+
+// owner returns the value of the user_id column for a user ID. An empty user
+// ID is a global template.
+func owner(userID string) interface{} {
+	if len(userID) == 0 {
+		return nil
+	}
+	return userID
+}
+
+// owned returns the condition that matches the items of a user ID and the
+// arguments with the user ID added. An empty user ID matches the global items.
+func owned(userID string, args ...interface{}) (string, []interface{}) {
+	if len(userID) == 0 {
+		return "user_id IS NULL", args
+	}
+	return "user_id = ?", append(args, userID)
+}
+
+// ByID gets an item by ID that belongs to the user. An empty user ID gets a
+// global item.
+func ByID(db Connection, ID string, userID string) (Item, bool, error) {
+	result := Item{}
+	where, args := owned(userID, ID)
+	err := db.Get(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, name, body, user_id, created_at, updated_at, deleted_at
+		FROM %v
+		WHERE id = ?
+			AND %v
+			AND deleted_at IS NULL
+		LIMIT 1
+		`, table, where)),
+		args...)
+	return result, err == sql.ErrNoRows, err
+}
+
+// ByUserID gets all items that belong to the user. An empty user ID gets the
+// global items.
+func ByUserID(db Connection, userID string) ([]Item, bool, error) {
+	var result []Item
+	where, args := owned(userID)
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, name, body, user_id, created_at, updated_at, deleted_at
+		FROM %v
+		WHERE %v
+			AND deleted_at IS NULL
+		ORDER BY name
+		`, table, where)),
+		args...)
+	return result, err == sql.ErrNoRows, err
+}
+
+// AvailableByID gets an item by ID that belongs to the user or is global.
+func AvailableByID(db Connection, ID string, userID string) (Item, bool, error) {
+	result := Item{}
+	err := db.Get(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, name, body, user_id, created_at, updated_at, deleted_at
+		FROM %v
+		WHERE id = ?
+			AND (user_id = ? OR user_id IS NULL)
+			AND deleted_at IS NULL
+		LIMIT 1
+		`, table)),
+		ID, userID)
+	return result, err == sql.ErrNoRows, err
+}
+
+// Available gets all items that belong to the user followed by the global
+// items.
+func Available(db Connection, userID string) ([]Item, bool, error) {
+	var result []Item
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, name, body, user_id, created_at, updated_at, deleted_at
+		FROM %v
+		WHERE (user_id = ? OR user_id IS NULL)
+			AND deleted_at IS NULL
+		ORDER BY user_id IS NULL, name
+		`, table)),
+		userID)
+	return result, err == sql.ErrNoRows, err
+}
+
+// Create adds an item. An empty user ID adds a global item.
+func Create(db Connection, name string, body string, userID string) (sql.Result, error) {
+	result, err := database.Insert(db, fmt.Sprintf(`
+		INSERT INTO %v
+		(name, body, user_id)
+		VALUES
+		(?,?,?)
+		`, table),
+		name, body, owner(userID))
+	return result, err
+}
+
+// Update makes changes to an existing item.
+func Update(db Connection, name string, body string, ID string, userID string) (sql.Result, error) {
+	where, args := owned(userID, name, body, ID)
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET name = ?,
+			body = ?
+		WHERE id = ?
+			AND %v
+			AND deleted_at IS NULL
+		`, table, where)),
+		args...)
+	return result, err
+}
+
+// DeleteSoft marks an item as removed.
+func DeleteSoft(db Connection, ID string, userID string) (sql.Result, error) {
+	where, args := owned(userID, ID)
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET deleted_at = NOW()
+		WHERE id = ?
+			AND %v
+			AND deleted_at IS NULL
+		`, table, where)),
+		args...)
+	return result, err
+}
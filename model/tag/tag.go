@@ -0,0 +1,149 @@
+// Package tag provides access to the tag and note_tag tables in the database.
+package tag
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/blue-jay/blueprint/lib/database"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// table is the table name.
+	table = "tag"
+	// noteTable is the table that links tags to notes.
+	noteTable = "note_tag"
+)
+
+// Item defines the model.
+type Item struct {
+	ID        uint32       `db:"id"`
+	Name      string       `db:"name"`
+	UserID    uint32       `db:"user_id"`
+	CreatedAt sql.NullTime `db:"created_at"`
+	UpdatedAt sql.NullTime `db:"updated_at"`
+	DeletedAt sql.NullTime `db:"deleted_at"`
+}
+
+// NoteItem defines a tag attached to a note.
+type NoteItem struct {
+	NoteID uint32 `db:"note_id"`
+	TagID  uint32 `db:"tag_id"`
+	Name   string `db:"name"`
+}
+
+// Connection is an interface for making queries.
+type Connection interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
+}
+
+// ByName gets an item by name.
+func ByName(db Connection, name string, userID string) (Item, bool, error) {
+	result := Item{}
+	err := db.Get(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, name, user_id, created_at, updated_at, deleted_at
+		FROM %v
+		WHERE name = ?
+			AND user_id = ?
+			AND deleted_at IS NULL
+		LIMIT 1
+		`, table)),
+		name, userID)
+	return result, err == sql.ErrNoRows, err
+}
+
+// ByUserID gets all items for a user.
+func ByUserID(db Connection, userID string) ([]Item, bool, error) {
+	var result []Item
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, name, user_id, created_at, updated_at, deleted_at
+		FROM %v
+		WHERE user_id = ?
+			AND deleted_at IS NULL
+		ORDER BY name
+		`, table)),
+		userID)
+	return result, err == sql.ErrNoRows, err
+}
+
+// ByNoteIDs gets the tags attached to each of the notes.
+func ByNoteIDs(db Connection, noteIDs []uint32) ([]NoteItem, error) {
+	var result []NoteItem
+	if len(noteIDs) == 0 {
+		return result, nil
+	}
+
+	query, args, err := sqlx.In(fmt.Sprintf(`
+		SELECT nt.note_id, t.id AS tag_id, t.name
+		FROM %v AS nt
+		INNER JOIN %v AS t ON t.id = nt.tag_id
+		WHERE nt.note_id IN (?)
+			AND t.deleted_at IS NULL
+		ORDER BY t.name
+		`, noteTable, table),
+		noteIDs)
+	if err != nil {
+		return result, err
+	}
+
+	err = db.Select(&result, db.Rebind(query), args...)
+	return result, err
+}
+
+// Create adds an item.
+func Create(db Connection, name string, userID string) (sql.Result, error) {
+	result, err := database.Insert(db, fmt.Sprintf(`
+		INSERT INTO %v
+		(name, user_id)
+		VALUES
+		(?,?)
+		`, table),
+		name, userID)
+	return result, err
+}
+
+// FirstOrCreate gets an item by name and adds it if it does not exist. Returns
+// the ID of the item.
+func FirstOrCreate(db Connection, name string, userID string) (string, error) {
+	item, noRows, err := ByName(db, name, userID)
+	if err == nil {
+		return fmt.Sprintf("%v", item.ID), nil
+	} else if !noRows {
+		return "", err
+	}
+
+	result, err := Create(db, name, userID)
+	if err != nil {
+		return "", err
+	}
+
+	ID, err := result.LastInsertId()
+	return fmt.Sprintf("%v", ID), err
+}
+
+// Attach links a tag to a note if the note belongs to the user.
+func Attach(db Connection, tagID string, noteID string, userID string) (sql.Result, error) {
+	insert, tag, conflict := "INSERT IGNORE INTO", "?", ""
+	if database.IsPostgreSQL(db) {
+		insert, tag, conflict = "INSERT INTO", "CAST(? AS INTEGER)", "ON CONFLICT DO NOTHING"
+	}
+
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		%v %v
+		(note_id, tag_id)
+		SELECT id, %v
+		FROM note
+		WHERE id = ?
+			AND user_id = ?
+			AND deleted_at IS NULL
+		%v
+		`, insert, noteTable, tag, conflict)),
+		tagID, noteID, userID)
+	return result, err
+}
@@ -1,29 +1,31 @@
-// Package user provides access to the user table in the MySQL database.
+// Package user provides access to the user table in the database.
 package user
 
 import (
 	"database/sql"
 	"fmt"
 
-	"github.com/go-sql-driver/mysql"
+	"github.com/blue-jay/blueprint/lib/database"
 )
 
 var (
-	// table is the table name.
+	// table is the table name. It is a reserved word in PostgreSQL so it is
+	// quoted in the queries.
 	table = "user"
 )
 
 // Item defines the model.
 type Item struct {
-	ID        uint32         `db:"id"`
-	FirstName string         `db:"first_name"`
-	LastName  string         `db:"last_name"`
-	Email     string         `db:"email"`
-	Password  string         `db:"password"`
-	StatusID  uint8          `db:"status_id"`
-	CreatedAt mysql.NullTime `db:"created_at"`
-	UpdatedAt mysql.NullTime `db:"updated_at"`
-	DeletedAt mysql.NullTime `db:"deleted_at"`
+	ID        uint32       `db:"id"`
+	FirstName string       `db:"first_name"`
+	LastName  string       `db:"last_name"`
+	Email     string       `db:"email"`
+	Password  string       `db:"password"`
+	StatusID  uint8        `db:"status_id"`
+	RoleID    uint8        `db:"role_id"`
+	CreatedAt sql.NullTime `db:"created_at"`
+	UpdatedAt sql.NullTime `db:"updated_at"`
+	DeletedAt sql.NullTime `db:"deleted_at"`
 }
 
 // Connection is an interface for making queries.
@@ -31,30 +33,32 @@ type Connection interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	Get(dest interface{}, query string, args ...interface{}) error
 	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
 }
 
 // ByEmail gets user information from email.
 func ByEmail(db Connection, email string) (Item, bool, error) {
 	result := Item{}
-	err := db.Get(&result, fmt.Sprintf(`
-		SELECT id, password, status_id, first_name
+	err := db.Get(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, password, status_id, role_id, first_name
 		FROM %v
 		WHERE email = ?
 			AND deleted_at IS NULL
 		LIMIT 1
-		`, table),
+		`, database.Quote(db, table))),
 		email)
 	return result, err == sql.ErrNoRows, err
 }
 
 // Create creates user.
 func Create(db Connection, firstName, lastName, email, password string) (sql.Result, error) {
-	result, err := db.Exec(fmt.Sprintf(`
+	result, err := database.Insert(db, fmt.Sprintf(`
 		INSERT INTO %v
 		(first_name, last_name, email, password)
 		VALUES
 		(?,?,?,?)
-		`, table),
+		`, database.Quote(db, table)),
 		firstName, lastName, email, password)
 	return result, err
 }
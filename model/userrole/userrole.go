@@ -0,0 +1,37 @@
+// Package userrole provides access to the user_role table in the database.
+package userrole
+
+import (
+	"database/sql"
+	"time"
+)
+
+var (
+	// table is the table name.
+	table = "user_role"
+)
+
+const (
+	// User is the role of a regular user.
+	User uint8 = 1
+	// Admin is the role of a user who can manage the application.
+	Admin uint8 = 2
+)
+
+// Item defines the model
+type Item struct {
+	ID        uint8     `db:"id"`
+	Role      string    `db:"role"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+	DeletedAt time.Time `db:"deleted_at"`
+}
+
+// Connection is an interface for making queries.
+type Connection interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
+}
@@ -1,4 +1,4 @@
-// Package userstatus provides access to the user_status table in the MySQL database.
+// Package userstatus provides access to the user_status table in the database.
 package userstatus
 
 import (
@@ -25,4 +25,6 @@ type Connection interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	Get(dest interface{}, query string, args ...interface{}) error
 	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
 }
@@ -0,0 +1,143 @@
+// Package webhook provides access to the webhook table in the database.
+// A webhook without a user is global and receives the events of every user.
+package webhook
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/blue-jay/blueprint/lib/database"
+)
+
+var (
+	// table is the table name.
+	table = "webhook"
+)
+
+// Item defines the model. Events is a comma separated list of event types.
+type Item struct {
+	ID        uint32        `db:"id"`
+	URL       string        `db:"url"`
+	Secret    string        `db:"secret"`
+	Events    string        `db:"events"`
+	UserID    sql.NullInt64 `db:"user_id"`
+	CreatedAt sql.NullTime  `db:"created_at"`
+	UpdatedAt sql.NullTime  `db:"updated_at"`
+	DeletedAt sql.NullTime  `db:"deleted_at"`
+}
+
+// Connection is an interface for making queries.
+type Connection interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
+}
+
+// owner returns the value of the user_id column for a user ID. An empty user
+// ID is a global webhook.
+func owner(userID string) interface{} {
+	if len(userID) == 0 {
+		return nil
+	}
+	return userID
+}
+
+// owned returns the condition that matches the items of a user ID and the
+// arguments with the user ID added. An empty user ID matches the global items.
+func owned(userID string, args ...interface{}) (string, []interface{}) {
+	if len(userID) == 0 {
+		return "user_id IS NULL", args
+	}
+	return "user_id = ?", append(args, userID)
+}
+
+// ByID gets an item by ID that belongs to the user. An empty user ID gets a
+// global item.
+func ByID(db Connection, ID string, userID string) (Item, bool, error) {
+	result := Item{}
+	where, args := owned(userID, ID)
+	err := db.Get(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, url, secret, events, user_id, created_at, updated_at, deleted_at
+		FROM %v
+		WHERE id = ?
+			AND %v
+			AND deleted_at IS NULL
+		LIMIT 1
+		`, table, where)),
+		args...)
+	return result, err == sql.ErrNoRows, err
+}
+
+// ByUserID gets all items that belong to the user. An empty user ID gets the
+// global items.
+func ByUserID(db Connection, userID string) ([]Item, bool, error) {
+	var result []Item
+	where, args := owned(userID)
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, url, secret, events, user_id, created_at, updated_at, deleted_at
+		FROM %v
+		WHERE %v
+			AND deleted_at IS NULL
+		ORDER BY id
+		`, table, where)),
+		args...)
+	return result, err == sql.ErrNoRows, err
+}
+
+// Subscribed gets the items that receive the event of a user. The global items
+// receive the events of every user.
+func Subscribed(db Connection, event string, userID string) ([]Item, error) {
+	var result []Item
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, url, secret, events, user_id, created_at, updated_at, deleted_at
+		FROM %v
+		WHERE CONCAT(',', events, ',') LIKE ?
+			AND (user_id IS NULL OR user_id = ?)
+			AND deleted_at IS NULL
+		`, table)),
+		"%,"+event+",%", userID)
+	return result, err
+}
+
+// Create adds an item.
+func Create(db Connection, url string, secret string, events string, userID string) (sql.Result, error) {
+	result, err := database.Insert(db, fmt.Sprintf(`
+		INSERT INTO %v
+		(url, secret, events, user_id)
+		VALUES
+		(?,?,?,?)
+		`, table),
+		url, secret, events, owner(userID))
+	return result, err
+}
+
+// Update makes changes to an existing item.
+func Update(db Connection, url string, events string, ID string, userID string) (sql.Result, error) {
+	where, args := owned(userID, url, events, ID)
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET url = ?,
+			events = ?
+		WHERE id = ?
+			AND %v
+			AND deleted_at IS NULL
+		`, table, where)),
+		args...)
+	return result, err
+}
+
+// DeleteSoft marks an item as removed.
+func DeleteSoft(db Connection, ID string, userID string) (sql.Result, error) {
+	where, args := owned(userID, ID)
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET deleted_at = NOW()
+		WHERE id = ?
+			AND %v
+			AND deleted_at IS NULL
+		`, table, where)),
+		args...)
+	return result, err
+}
@@ -0,0 +1,171 @@
+// Package webhookdelivery provides access to the webhook_delivery table in the
+// database. Each row is a payload waiting to be sent to a webhook or the result
+// of sending it. The attempts are run by the job queue.
+package webhookdelivery
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/blue-jay/blueprint/lib/database"
+)
+
+var (
+	// table is the table name.
+	table = "webhook_delivery"
+)
+
+// Item defines the model.
+type Item struct {
+	ID             uint32         `db:"id"`
+	WebhookID      uint32         `db:"webhook_id"`
+	Event          string         `db:"event"`
+	Payload        string         `db:"payload"`
+	Attempts       uint32         `db:"attempts"`
+	NextAttemptAt  sql.NullTime   `db:"next_attempt_at"`
+	DeliveredAt    sql.NullTime   `db:"delivered_at"`
+	FailedAt       sql.NullTime   `db:"failed_at"`
+	ResponseStatus sql.NullInt64  `db:"response_status"`
+	Error          sql.NullString `db:"error"`
+	CreatedAt      sql.NullTime   `db:"created_at"`
+	UpdatedAt      sql.NullTime   `db:"updated_at"`
+}
+
+// Pending defines a delivery with the details of its webhook.
+type Pending struct {
+	ID       uint32 `db:"id"`
+	Event    string `db:"event"`
+	Payload  string `db:"payload"`
+	Attempts uint32 `db:"attempts"`
+	URL      string `db:"url"`
+	Secret   string `db:"secret"`
+}
+
+// Connection is an interface for making queries.
+type Connection interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Rebind(query string) string
+	DriverName() string
+}
+
+// ByWebhookID gets the latest max items of a webhook.
+func ByWebhookID(db Connection, webhookID uint32, max int) ([]Item, error) {
+	var result []Item
+	err := db.Select(&result, db.Rebind(fmt.Sprintf(`
+		SELECT id, webhook_id, event, payload, attempts, next_attempt_at, delivered_at, failed_at, response_status, error,
+			created_at, updated_at
+		FROM %v
+		WHERE webhook_id = ?
+		ORDER BY id DESC
+		LIMIT %v
+		`, table, max)),
+		webhookID)
+	return result, err
+}
+
+// Create adds an item that is sent at the time now.
+func Create(db Connection, webhookID uint32, event string, payload string, now time.Time) (sql.Result, error) {
+	result, err := database.Insert(db, fmt.Sprintf(`
+		INSERT INTO %v
+		(webhook_id, event, payload, next_attempt_at)
+		VALUES
+		(?,?,?,?)
+		`, table),
+		webhookID, event, payload, now.UTC())
+	return result, err
+}
+
+// PendingByID gets an item that is waiting to be sent with the details of its
+// webhook. Items of removed webhooks are not returned.
+func PendingByID(db Connection, ID uint32) (Pending, bool, error) {
+	result := Pending{}
+	err := db.Get(&result, db.Rebind(fmt.Sprintf(`
+		SELECT d.id, d.event, d.payload, d.attempts, w.url, w.secret
+		FROM %v AS d
+		INNER JOIN webhook AS w ON w.id = d.webhook_id
+		WHERE d.id = ?
+			AND d.next_attempt_at IS NOT NULL
+			AND w.deleted_at IS NULL
+		`, table)),
+		ID)
+	return result, err == sql.ErrNoRows, err
+}
+
+// MarkDelivered records that the item was sent.
+func MarkDelivered(db Connection, ID uint32, status int, now time.Time) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET attempts = attempts + 1,
+			next_attempt_at = NULL,
+			delivered_at = ?,
+			response_status = ?,
+			error = NULL
+		WHERE id = ?
+		`, table)),
+		now.UTC(), status, ID)
+	return result, err
+}
+
+// MarkFailed records that the item could not be sent. The item is sent again
+// at the next time. A nil next time means the item is not sent again. A zero
+// status means there was no response.
+func MarkFailed(db Connection, ID uint32, status int, message string, next *time.Time, now time.Time) (sql.Result, error) {
+	var nextAt, failedAt, responseStatus interface{}
+	if next != nil {
+		nextAt = next.UTC()
+	} else {
+		failedAt = now.UTC()
+	}
+	if status != 0 {
+		responseStatus = status
+	}
+	if len(message) > 1000 {
+		message = message[:1000]
+		for !utf8.ValidString(message) {
+			message = message[:len(message)-1]
+		}
+	}
+
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET attempts = attempts + 1,
+			next_attempt_at = ?,
+			failed_at = ?,
+			response_status = ?,
+			error = ?
+		WHERE id = ?
+		`, table)),
+		nextAt, failedAt, responseStatus, message, ID)
+	return result, err
+}
+
+// Retry sends an item of a webhook again at the time now. Items that are
+// waiting to be sent are not changed.
+func Retry(db Connection, ID string, webhookID uint32, now time.Time) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		UPDATE %v
+		SET next_attempt_at = ?,
+			failed_at = NULL
+		WHERE id = ?
+			AND webhook_id = ?
+			AND next_attempt_at IS NULL
+		`, table)),
+		now.UTC(), ID, webhookID)
+	return result, err
+}
+
+// DeleteBefore removes the items that were delivered or failed before the
+// time.
+func DeleteBefore(db Connection, before time.Time) (sql.Result, error) {
+	result, err := db.Exec(db.Rebind(fmt.Sprintf(`
+		DELETE FROM %v
+		WHERE delivered_at < ?
+			OR failed_at < ?
+		`, table)),
+		before.UTC(), before.UTC())
+	return result, err
+}
@@ -0,0 +1,94 @@
+// Package pager provides a funcmap for html/template that displays page links
+// which keep the rest of the query string, like the sort and filter values.
+package pager
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strconv"
+)
+
+// Info holds the details for the page links.
+type Info struct {
+	Page       int
+	TotalPages int
+	Query      url.Values
+}
+
+// New returns the page links for count items split into pages of perPage
+// items where the current page starts at offset.
+func New(query url.Values, count int, perPage int, offset int) Info {
+	i := Info{
+		Page:  1,
+		Query: query,
+	}
+
+	if perPage > 0 {
+		i.Page = offset/perPage + 1
+		i.TotalPages = (count + perPage - 1) / perPage
+	}
+
+	return i
+}
+
+// URL returns the query string for a page.
+func (i Info) URL(page int) string {
+	q := url.Values{}
+	for k, v := range i.Query {
+		q[k] = v
+	}
+	q.Set("page", strconv.Itoa(page))
+	return "?" + q.Encode()
+}
+
+// Map returns a template.FuncMap for PAGER that returns the page links.
+func Map() template.FuncMap {
+	f := make(template.FuncMap)
+
+	f["PAGER"] = func(i Info, v map[string]interface{}) template.HTML {
+		if i.TotalPages < 2 {
+			return template.HTML("")
+		}
+
+		path := template.HTMLEscapeString(fmt.Sprint(v["CurrentURI"]))
+
+		var b bytes.Buffer
+		b.WriteString(`<nav><ul class="pagination">`)
+
+		link := func(page int, label string, class string) {
+			if len(class) > 0 {
+				fmt.Fprintf(&b, `<li class="%v"><span>%v</span></li>`, class, label)
+				return
+			}
+			fmt.Fprintf(&b, `<li><a href="%v%v">%v</a></li>`, path, template.HTMLEscapeString(i.URL(page)), label)
+		}
+
+		if i.Page > 1 {
+			link(i.Page-1, "&laquo;", "")
+		} else {
+			link(0, "&laquo;", "disabled")
+		}
+
+		for p := 1; p <= i.TotalPages; p++ {
+			if p == i.Page {
+				link(p, strconv.Itoa(p), "active")
+			} else {
+				link(p, strconv.Itoa(p), "")
+			}
+		}
+
+		if i.Page < i.TotalPages {
+			link(i.Page+1, "&raquo;", "")
+		} else {
+			link(0, "&raquo;", "disabled")
+		}
+
+		b.WriteString(`</ul></nav>`)
+
+		return template.HTML(b.String())
+	}
+
+	return f
+}
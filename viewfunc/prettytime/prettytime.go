@@ -3,9 +3,8 @@
 package prettytime
 
 import (
+	"database/sql"
 	"html/template"
-
-	"github.com/go-sql-driver/mysql"
 )
 
 // Map returns a template.FuncMap for NULLTIME and PRETTYTIME which outputs a
@@ -13,14 +12,14 @@ import (
 func Map() template.FuncMap {
 	f := make(template.FuncMap)
 
-	f["NULLTIME"] = func(t mysql.NullTime) string {
+	f["NULLTIME"] = func(t sql.NullTime) string {
 		if t.Valid {
 			return t.Time.Format("3:04 PM 01/02/2006")
 		}
 		return "null"
 	}
 
-	f["PRETTYTIME"] = func(createdAt mysql.NullTime, updatedAt mysql.NullTime) string {
+	f["PRETTYTIME"] = func(createdAt sql.NullTime, updatedAt sql.NullTime) string {
 		if updatedAt.Valid {
 			return updatedAt.Time.Format("3:04 PM 01/02/2006")
 		}
@@ -5,11 +5,13 @@ import (
 	"net/http"
 
 	"github.com/blue-jay/blueprint/lib/flight"
+	"github.com/blue-jay/blueprint/model/userrole"
 	"github.com/blue-jay/core/view"
 )
 
 // Modify sets AuthLevel in the template to auth if the user is authenticated.
-// Sets AuthLevel to anon if not authenticated.
+// Sets AuthLevel to anon if not authenticated. Sets IsAdmin to true if the user
+// has the admin role.
 func Modify(w http.ResponseWriter, r *http.Request, v *view.Info) {
 	c := flight.Context(w, r)
 
@@ -19,4 +21,6 @@ func Modify(w http.ResponseWriter, r *http.Request, v *view.Info) {
 	} else {
 		v.Vars["AuthLevel"] = "anon"
 	}
+
+	v.Vars["IsAdmin"] = c.Sess.Values["role_id"] == userrole.Admin
 }
@@ -58,8 +58,8 @@ func TestModify(t *testing.T) {
 	// Set up the session cookie store
 	s.SetupConfig()
 
-	// Set up flight
-	flight.StoreConfig(env.Info{
+	// Set up the application
+	app := flight.NewApp(env.Info{
 		Session: s,
 		View:    *viewInfo,
 	})
@@ -89,7 +89,7 @@ func TestModify(t *testing.T) {
 		}
 	})
 
-	handler.ServeHTTP(w, r)
+	app.Handler(handler).ServeHTTP(w, r)
 
 	actual := w.Body.String()
 	expected := fmt.Sprintf(`<div class="%v">%v</div>`, flash.Success, text)
@@ -97,9 +97,6 @@ func TestModify(t *testing.T) {
 	if actual != expected {
 		t.Fatalf("\nactual: %v\nexpected: %v", actual, expected)
 	}
-
-	// Reset flight after the tests
-	flight.Reset()
 }
 
 // TestModify ensures flashes are not displayed on the page.
@@ -143,8 +140,8 @@ func TestModifyFail(t *testing.T) {
 	// Set up the session cookie store
 	s.SetupConfig()
 
-	// Set up flight
-	flight.StoreConfig(env.Info{
+	// Set up the application
+	app := flight.NewApp(env.Info{
 		Session: s,
 		View:    *viewInfo,
 	})
@@ -174,7 +171,7 @@ func TestModifyFail(t *testing.T) {
 		}
 	})
 
-	handler.ServeHTTP(w, r)
+	app.Handler(handler).ServeHTTP(w, r)
 
 	actual := w.Body.String()
 	expected := "Failure!"
@@ -182,9 +179,6 @@ func TestModifyFail(t *testing.T) {
 	if actual != expected {
 		t.Fatalf("\nactual: %v\nexpected: %v", actual, expected)
 	}
-
-	// Reset flight after the tests
-	flight.Reset()
 }
 
 // TestFlashDefault ensures flashes are added to the view even if a plain text
@@ -229,8 +223,8 @@ func TestFlashDefault(t *testing.T) {
 	// Set up the session cookie store
 	s.SetupConfig()
 
-	// Set up flight
-	flight.StoreConfig(env.Info{
+	// Set up the application
+	app := flight.NewApp(env.Info{
 		Session: s,
 		View:    *viewInfo,
 	})
@@ -260,7 +254,7 @@ func TestFlashDefault(t *testing.T) {
 		}
 	})
 
-	handler.ServeHTTP(w, r)
+	app.Handler(handler).ServeHTTP(w, r)
 
 	actual := w.Body.String()
 	expected := fmt.Sprintf(`<div class="%v">%v</div>`, flash.Standard, text)
@@ -268,9 +262,6 @@ func TestFlashDefault(t *testing.T) {
 	if actual != expected {
 		t.Fatalf("\nactual: %v\nexpected: %v", actual, expected)
 	}
-
-	// Reset flight after the tests
-	flight.Reset()
 }
 
 // TestNonStringFlash ensures flashes do not error when added with a non-standard type.
@@ -314,8 +305,8 @@ func TestNonStringFlash(t *testing.T) {
 	// Set up the session cookie store
 	s.SetupConfig()
 
-	// Set up flight
-	flight.StoreConfig(env.Info{
+	// Set up the application
+	app := flight.NewApp(env.Info{
 		Session: s,
 		View:    *viewInfo,
 	})
@@ -345,7 +336,7 @@ func TestNonStringFlash(t *testing.T) {
 		}
 	})
 
-	handler.ServeHTTP(w, r)
+	app.Handler(handler).ServeHTTP(w, r)
 
 	actual := w.Body.String()
 	expected := fmt.Sprintf(`<div class="%v">%v</div>`, flash.Standard, text)
@@ -353,7 +344,4 @@ func TestNonStringFlash(t *testing.T) {
 	if actual != expected {
 		t.Fatalf("\nactual: %v\nexpected: %v", actual, expected)
 	}
-
-	// Reset flight after the tests
-	flight.Reset()
 }